@@ -0,0 +1,534 @@
+package tgbot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ArgType is the type of the command argument.
+type ArgType int
+
+const (
+	// ArgString is a word, use quotes for the value contains spaces.
+	ArgString ArgType = iota
+
+	// ArgInt is an integer, the value is int64.
+	ArgInt
+
+	// ArgFloat is a float number, the value is float64.
+	ArgFloat
+
+	// ArgBool is a boolean, the flag without value is true.
+	ArgBool
+
+	// ArgDuration is a duration such as "1h30m", the value is time.Duration.
+	ArgDuration
+
+	// ArgUser is a user mention, user id or username, the value is UserRef.
+	ArgUser
+
+	// ArgEnum is one of the Arg.Enum.
+	ArgEnum
+
+	// ArgText is the rest of the arguments as is, it must be the last positional argument.
+	ArgText
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgString:
+		return "string"
+	case ArgInt:
+		return "int"
+	case ArgFloat:
+		return "float"
+	case ArgBool:
+		return "bool"
+	case ArgDuration:
+		return "duration"
+	case ArgUser:
+		return "user"
+	case ArgEnum:
+		return "enum"
+	case ArgText:
+		return "text"
+	default:
+		return "unknown"
+	}
+}
+
+// Arg is the schema of a command argument.
+type Arg struct {
+	Name string
+	Type ArgType
+
+	// Flag report whether the argument is passed as --name=value or --name value,
+	// otherwise it is a positional argument.
+	Flag bool
+
+	Required bool
+
+	// Default is the value if the argument is not passed, it is parsed as Type.
+	Default string
+
+	// Enum is the allowed values of ArgEnum.
+	Enum []string
+
+	Description string
+}
+
+func (a *Arg) usage() string {
+	var s string
+	switch {
+	case a.Flag && a.Type == ArgBool:
+		s = "--" + a.Name
+	case a.Flag:
+		s = "--" + a.Name + "=" + a.placeholder()
+	case a.Type == ArgEnum:
+		s = strings.Join(a.Enum, "|")
+	case a.Type == ArgText:
+		s = a.Name + "..."
+	default:
+		s = a.Name
+	}
+
+	if a.Required {
+		return "<" + s + ">"
+	}
+	return "[" + s + "]"
+}
+
+func (a *Arg) placeholder() string {
+	if a.Type == ArgEnum {
+		return strings.Join(a.Enum, "|")
+	}
+	return a.Type.String()
+}
+
+// UserRef is the value of ArgUser, ID is zero if the user is referenced by username.
+type UserRef struct {
+	ID       int64
+	Username string
+
+	// User is non-nil if the user is mentioned by text mention.
+	User *tgbotapi.User
+}
+
+// ArgsError is the error of parsing command arguments.
+type ArgsError struct {
+	Command *Command
+	Arg     string
+	Msg     string
+}
+
+func (e *ArgsError) Error() string {
+	if e.Arg == "" {
+		return e.Msg
+	}
+	return e.Arg + ": " + e.Msg
+}
+
+// WithArgs set the argument schema of the command, the arguments are parsed before
+// the handler is called, the parsed values can be got by Context.Arg, and the usage
+// is replied if the arguments are invalid.
+func WithArgs(args ...Arg) CommandOption {
+	return func(cmd *Command) {
+		names := make(map[string]struct{}, len(args))
+		for i, arg := range args {
+			if _, ok := names[arg.Name]; ok {
+				panic("duplicate argument name: " + arg.Name)
+			}
+			names[arg.Name] = struct{}{}
+
+			if arg.Type == ArgText && (arg.Flag || i != len(args)-1) {
+				panic("tgbot: text argument must be the last positional argument: " + arg.Name)
+			}
+		}
+		cmd.args = args
+	}
+}
+
+// Usage return the usage of the command generated from the argument schema.
+func (c *Command) Usage() string {
+	if c.usage != "" {
+		return c.usage
+	}
+
+	var b strings.Builder
+	b.WriteString("/" + c.Name)
+	for i := range c.args {
+		b.WriteByte(' ')
+		b.WriteString(c.args[i].usage())
+	}
+	return b.String()
+}
+
+// Args return the argument schema of the command.
+func (c *Command) Args() []Arg {
+	return c.args
+}
+
+// argToken is a token of the command arguments.
+type argToken struct {
+	text   string
+	quoted bool
+
+	// start is the byte offset in the arguments.
+	start int
+
+	// user is the user of the text mention.
+	user *tgbotapi.User
+}
+
+// mentionSpan is the byte range of a text mention in the arguments.
+type mentionSpan struct {
+	start, end int
+	user       *tgbotapi.User
+}
+
+// byteOffset convert the utf16 offset of the text to byte offset.
+func byteOffset(text string, utf16Offset int) int {
+	n := 0
+	for i, r := range text {
+		if n >= utf16Offset {
+			return i
+		}
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return len(text)
+}
+
+// commandArguments return the arguments of the command message and the text mentions in it.
+func commandArguments(msg *tgbotapi.Message) (string, []mentionSpan) {
+	args := msg.CommandArguments()
+	if args == "" {
+		return "", nil
+	}
+	base := len(msg.Text) - len(args)
+
+	var spans []mentionSpan
+	for _, e := range msg.Entities {
+		if e.Type != "text_mention" || e.User == nil {
+			continue
+		}
+
+		start := byteOffset(msg.Text, e.Offset) - base
+		end := byteOffset(msg.Text, e.Offset+e.Length) - base
+		if start >= 0 {
+			spans = append(spans, mentionSpan{start: start, end: end, user: e.User})
+		}
+	}
+	return args, spans
+}
+
+// tokenizeArgs split the arguments by spaces, the single or double quoted value
+// is a token, backslash escapes the next character, the text mention is a token.
+func tokenizeArgs(args string, mentions []mentionSpan) ([]argToken, error) {
+	var (
+		tokens []argToken
+		i      int
+	)
+
+	for i < len(args) {
+		r, size := utf8.DecodeRuneInString(args[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		if m := findMention(mentions, i); m != nil {
+			tokens = append(tokens, argToken{text: args[m.start:m.end], start: i, user: m.user})
+			i = m.end
+			continue
+		}
+
+		var (
+			b      strings.Builder
+			start  = i
+			quote  rune
+			quoted bool
+		)
+	scan:
+		for i < len(args) {
+			r, size = utf8.DecodeRuneInString(args[i:])
+			switch {
+			case r == '\\' && i+size < len(args):
+				i += size
+				r, size = utf8.DecodeRuneInString(args[i:])
+				b.WriteRune(r)
+
+			case quote != 0 && r == quote:
+				quote = 0
+
+			case quote == 0 && (r == '"' || r == '\''):
+				quote, quoted = r, true
+
+			case quote == 0 && unicode.IsSpace(r):
+				break scan
+
+			default:
+				b.WriteRune(r)
+			}
+			i += size
+		}
+
+		if quote != 0 {
+			return nil, &ArgsError{Msg: "unterminated quote"}
+		}
+		tokens = append(tokens, argToken{text: b.String(), quoted: quoted, start: start})
+	}
+
+	return tokens, nil
+}
+
+func findMention(mentions []mentionSpan, start int) *mentionSpan {
+	for i := range mentions {
+		if mentions[i].start == start {
+			return &mentions[i]
+		}
+	}
+	return nil
+}
+
+// parseArgValue parse the value of the argument by its type.
+func parseArgValue(arg *Arg, tok argToken) (interface{}, error) {
+	s := tok.text
+	switch arg.Type {
+	case ArgInt:
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid integer %q", s)
+
+	case ArgFloat:
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid number %q", s)
+
+	case ArgBool:
+		switch strings.ToLower(s) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid bool %q", s)
+
+	case ArgDuration:
+		if v, err := time.ParseDuration(s); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid duration %q, e.g. 1h30m", s)
+
+	case ArgUser:
+		if tok.user != nil {
+			return UserRef{ID: tok.user.ID, Username: tok.user.UserName, User: tok.user}, nil
+		}
+		if strings.HasPrefix(s, "@") && len(s) > 1 {
+			return UserRef{Username: s[1:]}, nil
+		}
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return UserRef{ID: id}, nil
+		}
+		return nil, fmt.Errorf("invalid user %q, must be a mention or user id", s)
+
+	case ArgEnum:
+		for _, v := range arg.Enum {
+			if v == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(arg.Enum, ", "))
+
+	default:
+		return s, nil
+	}
+}
+
+// parseArgs parse the arguments by the schema.
+func parseArgs(schema []Arg, args string, mentions []mentionSpan) (map[string]interface{}, error) {
+	tokens, err := tokenizeArgs(args, mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		values     = make(map[string]interface{}, len(schema))
+		flags      = make(map[string]*Arg)
+		positional []*Arg
+	)
+	for i := range schema {
+		if schema[i].Flag {
+			flags[schema[i].Name] = &schema[i]
+		} else {
+			positional = append(positional, &schema[i])
+		}
+	}
+
+	set := func(arg *Arg, tok argToken) error {
+		v, err := parseArgValue(arg, tok)
+		if err != nil {
+			return &ArgsError{Arg: arg.Name, Msg: err.Error()}
+		}
+		values[arg.Name] = v
+		return nil
+	}
+
+loop:
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		if !tok.quoted && tok.user == nil && strings.HasPrefix(tok.text, "--") && len(tok.text) > 2 {
+			name, value, hasValue := strings.Cut(tok.text[2:], "=")
+			arg, ok := flags[name]
+			if !ok {
+				return nil, &ArgsError{Msg: "unknown flag --" + name}
+			}
+
+			switch {
+			case hasValue:
+				tok.text = value
+			case arg.Type == ArgBool:
+				tok.text = "true"
+			case i+1 < len(tokens):
+				i++
+				tok = tokens[i]
+			default:
+				return nil, &ArgsError{Arg: arg.Name, Msg: "missing value"}
+			}
+
+			if err := set(arg, tok); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if len(positional) == 0 {
+			return nil, &ArgsError{Msg: "too many arguments"}
+		}
+
+		arg := positional[0]
+		positional = positional[1:]
+
+		if arg.Type == ArgText {
+			values[arg.Name] = strings.TrimSpace(args[tok.start:])
+			break loop
+		}
+
+		if err := set(arg, tok); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range schema {
+		arg := &schema[i]
+		if _, ok := values[arg.Name]; ok {
+			continue
+		}
+
+		if arg.Required {
+			return nil, &ArgsError{Arg: arg.Name, Msg: "is required"}
+		}
+
+		if arg.Default != "" {
+			if err := set(arg, argToken{text: arg.Default}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// argsHandler wraps the handler to parse the arguments of the command before it is called.
+func (bot *Bot) argsHandler(cmd *Command, h Handler) Handler {
+	return func(ctx *Context) error {
+		var (
+			args     string
+			mentions []mentionSpan
+		)
+		if msg := ctx.Message(); msg != nil {
+			args, mentions = commandArguments(msg)
+		}
+
+		values, err := parseArgs(cmd.args, args, mentions)
+		if err != nil {
+			argsErr, ok := err.(*ArgsError)
+			if !ok {
+				return err
+			}
+			argsErr.Command = cmd
+			ctx.argsInvalid = true
+			return bot.argsErrorHandler(ctx, argsErr)
+		}
+
+		ctx.args = values
+		return h(ctx)
+	}
+}
+
+func (bot *Bot) argsErrorHandler(ctx *Context, err *ArgsError) error {
+	if bot.opts.argsErrorHandler != nil {
+		return bot.opts.argsErrorHandler(ctx, err)
+	}
+
+	return ctx.ReplyText(fmt.Sprintf("%s\nUsage: %s", err.Error(), err.Command.Usage()))
+}
+
+// Arg return the value of the parsed command argument, nil if it is not passed.
+func (c *Context) Arg(name string) interface{} {
+	return c.args[name]
+}
+
+// ArgString return the value of the ArgString, ArgEnum or ArgText argument.
+func (c *Context) ArgString(name string) string {
+	v, _ := c.args[name].(string)
+	return v
+}
+
+// ArgInt return the value of the ArgInt argument.
+func (c *Context) ArgInt(name string) int64 {
+	v, _ := c.args[name].(int64)
+	return v
+}
+
+// ArgFloat return the value of the ArgFloat argument.
+func (c *Context) ArgFloat(name string) float64 {
+	v, _ := c.args[name].(float64)
+	return v
+}
+
+// ArgBool return the value of the ArgBool argument.
+func (c *Context) ArgBool(name string) bool {
+	v, _ := c.args[name].(bool)
+	return v
+}
+
+// ArgDuration return the value of the ArgDuration argument.
+func (c *Context) ArgDuration(name string) time.Duration {
+	v, _ := c.args[name].(time.Duration)
+	return v
+}
+
+// ArgUser return the value of the ArgUser argument.
+func (c *Context) ArgUser(name string) (UserRef, bool) {
+	v, ok := c.args[name].(UserRef)
+	return v, ok
+}
+
+// HasArg report whether the argument is passed or has default value.
+func (c *Context) HasArg(name string) bool {
+	_, ok := c.args[name]
+	return ok
+}
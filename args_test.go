@@ -0,0 +1,105 @@
+package tgbot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseArgs(t *testing.T) {
+	schema := []Arg{
+		{Name: "user", Type: ArgUser, Required: true},
+		{Name: "count", Type: ArgInt, Default: "1"},
+		{Name: "for", Type: ArgDuration, Flag: true},
+		{Name: "silent", Type: ArgBool, Flag: true},
+		{Name: "level", Type: ArgEnum, Flag: true, Enum: []string{"low", "high"}},
+		{Name: "reason", Type: ArgText},
+	}
+
+	tests := []struct {
+		args   string
+		values map[string]interface{}
+		err    string
+	}{
+		{
+			args:   "@bob",
+			values: map[string]interface{}{"user": UserRef{Username: "bob"}, "count": int64(1)},
+		},
+		{
+			args: `42 3 --for 1h --silent --level=high too "much spam"`,
+			values: map[string]interface{}{
+				"user":   UserRef{ID: 42},
+				"count":  int64(3),
+				"for":    time.Hour,
+				"silent": true,
+				"level":  "high",
+				"reason": `too "much spam"`,
+			},
+		},
+		{args: "", err: "user: is required"},
+		{args: "@bob x", err: `count: invalid integer "x"`},
+		{args: "@bob --level=mid", err: "level: must be one of low, high"},
+		{args: "@bob --unknown", err: "unknown flag --unknown"},
+		{args: `@bob "open`, err: "unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		values, err := parseArgs(schema, tt.args, nil)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("parse %q except error %q, got: %v", tt.args, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parse %q error: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("parse %q except %v, got: %v", tt.args, tt.values, values)
+		}
+	}
+}
+
+func TestCommandArgumentsTextMention(t *testing.T) {
+	user := &tgbotapi.User{ID: 7, FirstName: "John"}
+	msg := &tgbotapi.Message{
+		Text: "/ban 😀 John Smith now",
+		Entities: []tgbotapi.MessageEntity{
+			{Type: "bot_command", Offset: 0, Length: 4},
+			{Type: "text_mention", Offset: 8, Length: 10, User: user},
+		},
+	}
+
+	args, mentions := commandArguments(msg)
+	values, err := parseArgs([]Arg{
+		{Name: "emoji", Type: ArgString},
+		{Name: "user", Type: ArgUser},
+		{Name: "when", Type: ArgString},
+	}, args, mentions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref := values["user"].(UserRef); ref.ID != 7 || ref.User != user {
+		t.Errorf("user except %d, got: %+v", 7, ref)
+	}
+	if values["when"] != "now" {
+		t.Errorf("when except %q, got: %v", "now", values["when"])
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	cmd := NewCommand("ban", "ban user", func(ctx *Context) error { return nil }, WithArgs(
+		Arg{Name: "user", Type: ArgUser, Required: true},
+		Arg{Name: "for", Type: ArgDuration, Flag: true},
+		Arg{Name: "reason", Type: ArgText},
+	))
+
+	except := "/ban <user> [--for=duration] [reason...]"
+	if usage := cmd.Usage(); usage != except {
+		t.Errorf("usage except %q, got: %q", except, usage)
+	}
+}
@@ -0,0 +1,169 @@
+package tgbot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CircuitState is the state of the circuit breaker of getting updates.
+type CircuitState int
+
+const (
+	// CircuitClosed getting updates works normally.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen getting updates fails continuously, it is paused until the cooldown elapsed.
+	CircuitOpen
+
+	// CircuitHalfOpen the cooldown elapsed, a single getting updates is sent as a probe,
+	// the circuit is closed if it succeeds, otherwise it is open again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Status is the status of getting updates.
+type Status struct {
+	Circuit CircuitState
+
+	// Failures is the number of consecutive failures.
+	Failures int
+
+	LastError   error
+	LastErrorAt time.Time
+
+	// OpenUntil is the time the open circuit becomes half-open.
+	OpenUntil time.Time
+}
+
+// breaker is the circuit breaker of getting updates.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu     sync.RWMutex
+	status Status
+}
+
+func (b *breaker) get() Status {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.status
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.Circuit = CircuitClosed
+	b.status.Failures = 0
+	b.status.OpenUntil = time.Time{}
+}
+
+// failure record the failure and return the number of consecutive failures and whether the
+// circuit is open, the failed probe of the half-open circuit opens the circuit again.
+func (b *breaker) failure(err error, trip bool) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.status.Failures++
+	b.status.LastError = err
+	b.status.LastErrorAt = now
+	if trip && (b.status.Circuit == CircuitHalfOpen || b.status.Failures >= b.threshold) {
+		b.status.Circuit = CircuitOpen
+		b.status.OpenUntil = now.Add(b.cooldown)
+	}
+	return b.status.Failures, b.status.Circuit == CircuitOpen
+}
+
+// allow return how long to wait before the next request, the open circuit
+// becomes half-open after the cooldown elapsed.
+func (b *breaker) allow() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.status.Circuit != CircuitOpen {
+		return 0
+	}
+
+	if d := time.Until(b.status.OpenUntil); d > 0 {
+		return d
+	}
+	b.status.Circuit = CircuitHalfOpen
+	return 0
+}
+
+// probing report whether the circuit is half-open, so the request is a single probe.
+func (b *breaker) probing() bool {
+	return b.get().Circuit == CircuitHalfOpen
+}
+
+// waitCircuit wait until the circuit allows getting updates, report false if the bot is stopped.
+func (bot *Bot) waitCircuit() bool {
+	for {
+		d := bot.breaker.allow()
+		if d <= 0 {
+			return true
+		}
+		if !sleepContext(bot.ctx, d) {
+			return false
+		}
+	}
+}
+
+// Status return the status of getting updates.
+func (bot *Bot) Status() Status {
+	return bot.breaker.get()
+}
+
+func apiErrorCode(err error) int {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+// handlePollUpdatesError handle the error of getting updates, it waits the backoff
+// before the next attempt, report false if polling must stop.
+func (bot *Bot) handlePollUpdatesError(err error) bool {
+	switch apiErrorCode(err) {
+	case http.StatusUnauthorized:
+		// the token is invalid, retry is pointless.
+		bot.fatalErr = err
+		bot.cancel()
+		return false
+
+	case http.StatusConflict:
+		// another getUpdates or webhook is active, telegram is available,
+		// so it does not trip the circuit breaker.
+		failures, _ := bot.breaker.failure(err, false)
+		bot.opts.pollUpdatesErrorHandler(fmt.Errorf("another getUpdates or webhook is active, error: %w", err))
+		return sleepContext(bot.ctx, bot.opts.pollUpdatesBackoff.Backoff(failures))
+	}
+
+	failures, open := bot.breaker.failure(err, true)
+	bot.opts.pollUpdatesErrorHandler(err)
+
+	// the open circuit pauses polling for the cooldown in waitCircuit.
+	if open {
+		return true
+	}
+	return sleepContext(bot.ctx, bot.opts.pollUpdatesBackoff.Backoff(failures))
+}
@@ -0,0 +1,103 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newTestAPI(t *testing.T, h http.HandlerFunc) *tgbotapi.BotAPI {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	api := &tgbotapi.BotAPI{Client: srv.Client()}
+	api.SetAPIEndpoint(srv.URL + "/bot%s/%s")
+	return api
+}
+
+func TestRunUnauthorized(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": false, "error_code": 401, "description": "Unauthorized"}`)
+	})
+
+	bot := NewBot(api)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.Run()
+	}()
+
+	select {
+	case err := <-done:
+		if apiErrorCode(err) != http.StatusUnauthorized {
+			t.Errorf("Run except unauthorized error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		bot.Stop()
+		t.Fatal("Run must return on unauthorized")
+	}
+}
+
+func TestCircuitBreakerOpen(t *testing.T) {
+	var (
+		requests int32
+		fail     int32 = 1
+	)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			fmt.Fprint(w, `{"ok": false, "error_code": 502, "description": "Bad Gateway"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true, "result": []}`)
+	})
+
+	bot := NewBot(api,
+		WithCircuitBreakerThreshold(2),
+		WithCircuitBreakerCooldown(100*time.Millisecond),
+		WithPollUpdatesBackoff(ExponentialBackoff(time.Millisecond, time.Millisecond, 0)),
+	)
+	go bot.Run()
+	defer bot.Stop()
+
+	waitStatus := func(circuit CircuitState) {
+		deadline := time.Now().Add(time.Second)
+		for bot.Status().Circuit != circuit {
+			if time.Now().After(deadline) {
+				t.Fatalf("circuit except %v, got: %v", circuit, bot.Status().Circuit)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitStatus(CircuitOpen)
+	if s := bot.Status(); s.LastError == nil || s.OpenUntil.IsZero() {
+		t.Errorf("open circuit except last error and open until, got: %v, %v", s.LastError, s.OpenUntil)
+	}
+
+	// polling is paused while the circuit is open.
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests while open except %d, got: %d", 2, n)
+	}
+
+	// the failed probe opens the circuit again after a single request.
+	time.Sleep(80 * time.Millisecond)
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("requests after probe except %d, got: %d", 3, n)
+	}
+	if s := bot.Status(); s.Circuit != CircuitOpen {
+		t.Errorf("circuit after failed probe except %v, got: %v", CircuitOpen, s.Circuit)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	waitStatus(CircuitClosed)
+	if s := bot.Status(); s.Failures != 0 {
+		t.Errorf("failures after successful probe except 0, got: %d", s.Failures)
+	}
+}
@@ -0,0 +1,177 @@
+package tgbot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callbackRoute is a callback query route registered by OnCallback.
+type callbackRoute struct {
+	re      *regexp.Regexp
+	handler Handler
+}
+
+var callbackParamRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// compileCallbackPattern compile the callback pattern to regexp, the pattern
+// matches the whole callback data, {name} captures a parameter and a trailing
+// "*" matches any suffix, so "vote:*" is a prefix match.
+func compileCallbackPattern(pattern string) (*regexp.Regexp, error) {
+	prefix := strings.HasSuffix(pattern, "*")
+	if prefix {
+		pattern = strings.TrimSuffix(pattern, "*")
+	}
+
+	var (
+		builder strings.Builder
+		last    int
+		names   = make(map[string]struct{})
+	)
+	builder.WriteByte('^')
+	for _, loc := range callbackParamRe.FindAllStringSubmatchIndex(pattern, -1) {
+		name := pattern[loc[2]:loc[3]]
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate parameter name: %s", name)
+		}
+		names[name] = struct{}{}
+
+		builder.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		builder.WriteString("(?P<" + name + ">.*?)")
+		last = loc[1]
+	}
+	builder.WriteString(regexp.QuoteMeta(pattern[last:]))
+	if prefix {
+		builder.WriteString(".*")
+	}
+	builder.WriteByte('$')
+
+	return regexp.Compile(builder.String())
+}
+
+func (r *callbackRoute) match(data string) (map[string]string, bool) {
+	matches := r.re.FindStringSubmatch(data)
+	if matches == nil {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, name := range r.re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = matches[i]
+	}
+	return params, true
+}
+
+// OnCallback register the handler for the callback queries whose data matches the pattern.
+//
+// The pattern matches the whole callback data, "{name}" captures a parameter which
+// can be got by Context.Param, and a trailing "*" makes it a prefix match, e.g.
+// "vote:{id}:{choice}" or "menu:*". The routes are matched in registration order.
+//
+// The callback query is answered automatically if the handler does not answer it.
+func (bot *Bot) OnCallback(pattern string, h Handler) {
+	if h == nil {
+		panic("tgbot: callback handler must be non-nil")
+	}
+
+	re, err := compileCallbackPattern(pattern)
+	if err != nil {
+		panic("tgbot: invalid callback pattern " + pattern + ": " + err.Error())
+	}
+
+	bot.callbacks = append(bot.callbacks, &callbackRoute{re: re, handler: h})
+}
+
+func (bot *Bot) callbackHandler(ctx *Context) error {
+	handler := bot.undefinedCallbackHandler
+	if h, ok := bot.handlers[tgbotapi.UpdateTypeCallbackQuery]; ok {
+		handler = h
+	}
+
+	data := ctx.CallbackData()
+	for _, route := range bot.callbacks {
+		if params, ok := route.match(data); ok {
+			ctx.params = params
+			handler = route.handler
+			break
+		}
+	}
+
+	err := handler(ctx)
+	bot.answerCallback(ctx)
+	return err
+}
+
+// answerCallback answer the callback query of the context if the handler does not answer it.
+func (bot *Bot) answerCallback(ctx *Context) {
+	if ctx.CallbackQuery() == nil || ctx.callbackAnswered {
+		return
+	}
+
+	if err := ctx.AnswerCallback(""); err != nil {
+		bot.opts.errHandler(fmt.Errorf("failed to answer callback query, error: %w", err))
+	}
+}
+
+func (bot *Bot) undefinedCallbackHandler(ctx *Context) error {
+	if bot.opts.undefinedCallbackHandler != nil {
+		return bot.opts.undefinedCallbackHandler(ctx)
+	}
+
+	return nil
+}
+
+// CallbackQuery return the callback query if the update is a callback query.
+func (c *Context) CallbackQuery() *tgbotapi.CallbackQuery {
+	if c.update == nil {
+		return nil
+	}
+	return c.update.CallbackQuery
+}
+
+// CallbackData return the callback data if the update is a callback query.
+func (c *Context) CallbackData() string {
+	if c.update == nil {
+		return ""
+	}
+	return c.update.CallbackData()
+}
+
+// AnswerCallback answer the current callback query, text is optional.
+func (c *Context) AnswerCallback(text string) error {
+	return c.answerCallback(text, false)
+}
+
+// AnswerCallbackWithAlert answer the current callback query with an alert.
+func (c *Context) AnswerCallbackWithAlert(text string) error {
+	return c.answerCallback(text, true)
+}
+
+func (c *Context) answerCallback(text string, alert bool) error {
+	query := c.CallbackQuery()
+	if query == nil {
+		return nil
+	}
+
+	cb := tgbotapi.NewCallback(query.ID, text)
+	cb.ShowAlert = alert
+	return c.SendReply(cb)
+}
+
+// Param return the parameter captured by the callback pattern.
+func (c *Context) Param(name string) string {
+	return c.params[name]
+}
+
+// Params return all the parameters captured by the callback pattern.
+func (c *Context) Params() map[string]string {
+	return c.params
+}
@@ -0,0 +1,82 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCallbackRouteMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		data    string
+		ok      bool
+		params  map[string]string
+	}{
+		{pattern: "ping", data: "ping", ok: true},
+		{pattern: "ping", data: "ping2", ok: false},
+		{pattern: "menu:*", data: "menu:settings", ok: true},
+		{pattern: "menu:*", data: "main", ok: false},
+		{pattern: "a.b", data: "axb", ok: false},
+		{
+			pattern: "vote:{id}:{choice}",
+			data:    "vote:42:yes",
+			ok:      true,
+			params:  map[string]string{"id": "42", "choice": "yes"},
+		},
+		{
+			pattern: "page:{n}*",
+			data:    "page:",
+			ok:      true,
+			params:  map[string]string{"n": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		re, err := compileCallbackPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("compile %q error: %v", tt.pattern, err)
+		}
+
+		route := &callbackRoute{re: re}
+		params, ok := route.match(tt.data)
+		if ok != tt.ok {
+			t.Errorf("%q match %q except %v, got: %v", tt.pattern, tt.data, tt.ok, ok)
+		}
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("%q match %q params except %v, got: %v", tt.pattern, tt.data, tt.params, params)
+		}
+	}
+
+	if _, err := compileCallbackPattern("{id}:{id}"); err == nil {
+		t.Error("duplicate parameter name must be error")
+	}
+}
+
+func TestCallbackAnsweredByRequest(t *testing.T) {
+	var answered int
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/answerCallbackQuery") {
+			answered++
+		}
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	})
+
+	bot := NewBot(api)
+	bot.OnCallback("vote", func(ctx *Context) error {
+		_, err := ctx.Request(tgbotapi.NewCallback(ctx.CallbackQuery().ID, "thanks"))
+		return err
+	})
+
+	bot.makeUpdateHandler(&tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "q", From: &tgbotapi.User{ID: 2}, Data: "vote", Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}},
+	}})()
+
+	if answered != 1 {
+		t.Errorf("answered callbacks except 1, got: %v", answered)
+	}
+}
@@ -2,7 +2,9 @@ package tgbot
 
 import (
 	"context"
+	"mime"
 	"net/http"
+	"path"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -10,12 +12,59 @@ import (
 type client struct {
 	cli tgbotapi.HTTPClient
 	ctx context.Context
+
+	// limiter is non-nil if the rate limiter is specified.
+	limiter RateLimiter
 }
 
 func (c *client) withContext(ctx context.Context) *client {
-	return &client{cli: c.cli, ctx: ctx}
+	return &client{cli: c.cli, ctx: ctx, limiter: c.limiter}
 }
 
 func (c *client) Do(req *http.Request) (*http.Response, error) {
-	return c.cli.Do(req.WithContext(c.ctx))
+	// use the request context if the client context is not specified.
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+
+	if c.limiter != nil {
+		if method := path.Base(req.URL.Path); isRateLimitedMethod(method) {
+			chatID, err := requestChatID(req)
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+
+			if err := c.limiter.Wait(req.Context(), method, chatID); err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+		}
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the 5xx responses of the gateways, such as 502 and 504 in HTML, can not be decoded
+	// by tgbotapi, so they are returned as the server errors to be retried.
+	if resp.StatusCode >= http.StatusInternalServerError && !isJSONResponse(resp) {
+		resp.Body.Close()
+		return nil, &tgbotapi.Error{Code: resp.StatusCode, Message: resp.Status}
+	}
+	return resp, nil
+}
+
+func isJSONResponse(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// closeRequestBody close the body of the request which is not sent like http.Client.Do does,
+// so the writer of the upload body, such as the pipe of tgbotapi, is not blocked forever.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
 }
@@ -57,6 +57,25 @@ type Command struct {
 
 	hide   bool // hide the command on telegram commands menu.
 	scopes []CommandScope
+
+	// middlewares wrap the command handler, run after the bot middlewares.
+	middlewares []Middleware
+
+	// conversation is started by the command.
+	conversation *Conversation
+
+	// args is the argument schema.
+	args []Arg
+
+	longDescription string
+	usage           string
+
+	aliases []string
+
+	// roles is the groups of roles required to run the command.
+	roles [][]Role
+
+	cooldown *cooldown
 }
 
 type CommandOption func(cmd *Command)
@@ -81,6 +100,27 @@ func WithScopes(scopes ...CommandScope) CommandOption {
 	}
 }
 
+// WithAliases set the aliases of the command, the aliases are not shown on telegram commands menu.
+func WithAliases(aliases ...string) CommandOption {
+	return func(cmd *Command) {
+		cmd.aliases = aliases
+	}
+}
+
+// WithMiddlewares set the command middlewares.
+func WithMiddlewares(middlewares ...Middleware) CommandOption {
+	return func(cmd *Command) {
+		cmd.middlewares = append(cmd.middlewares, middlewares...)
+	}
+}
+
+// WithConversation start the conversation after the command is handled successfully.
+func WithConversation(conv *Conversation) CommandOption {
+	return func(cmd *Command) {
+		cmd.conversation = conv
+	}
+}
+
 func NewCommand(name, desc string, handler Handler, opts ...CommandOption) *Command {
 	cmd := &Command{
 		Name:        name,
@@ -105,6 +145,10 @@ func (c *Command) Scopes() []CommandScope {
 	return c.scopes
 }
 
+func (c *Command) Aliases() []string {
+	return c.aliases
+}
+
 func CommandScopeNoScope() CommandScope {
 	return noScope
 }
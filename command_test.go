@@ -0,0 +1,48 @@
+package tgbot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCommandAliases(t *testing.T) {
+	var calls int
+	bot := NewBot(&tgbotapi.BotAPI{Self: tgbotapi.User{UserName: "MyBot"}}, WithCaseInsensitiveCommands(true))
+	bot.AddCommands(NewCommand("start", "start", func(ctx *Context) error {
+		calls++
+		return nil
+	}, WithAliases("begin")))
+
+	tests := []struct {
+		text  string
+		calls int
+	}{
+		{text: "/start", calls: 1},
+		{text: "/begin", calls: 2},
+		{text: "/BEGIN@mybot", calls: 3},
+		{text: "/start@otherbot", calls: 3},
+	}
+
+	for _, tt := range tests {
+		bot.makeUpdateHandler(newCommandUpdate(tt.text))()
+		if calls != tt.calls {
+			t.Errorf("%s calls except %v, got: %v", tt.text, tt.calls, calls)
+		}
+	}
+}
+
+func TestCommandAliasDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("except panic on duplicate alias")
+		}
+	}()
+
+	noop := func(ctx *Context) error { return nil }
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.AddCommands(
+		NewCommand("start", "start", noop),
+		NewCommand("begin", "begin", noop, WithAliases("start")),
+	)
+}
@@ -2,6 +2,7 @@ package tgbot
 
 import (
 	"context"
+	"encoding/json"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -11,7 +12,33 @@ type Context struct {
 
 	*tgbotapi.BotAPI
 
+	bot *Bot
+
 	update *tgbotapi.Update
+
+	// params is the parameters captured by the callback pattern.
+	params map[string]string
+
+	// callbackAnswered report whether the callback query has been answered.
+	callbackAnswered bool
+
+	// conversation is the conversation of the current user in the chat.
+	conversation *conversationSession
+
+	// session is loaded lazily by Session.
+	session *Session
+
+	// args is the parsed command arguments.
+	args map[string]interface{}
+
+	// argsInvalid report whether the command arguments are invalid, the conversation is not started then.
+	argsInvalid bool
+
+	// deepLinkPayload is the payload of the routed deep link after the prefix.
+	deepLinkPayload string
+
+	// deepLinkPrefix is the prefix of the routed deep link, the signature covers it.
+	deepLinkPrefix string
 }
 
 // Command return command name if message is non-nil.
@@ -86,28 +113,53 @@ type MessageOption func(c *tgbotapi.MessageConfig)
 
 // ReplyText reply to the current chat.
 func (c *Context) ReplyText(text string, opts ...MessageOption) error {
-	return c.reply(text, mergeOpts(opts,
+	_, err := c.reply(text, mergeOpts(opts,
 		WithDisableWebPagePreview(true),
 	)...)
+	return err
 }
 
 // ReplyMarkdown reply to the current chat, text format is markdown.
 func (c *Context) ReplyMarkdown(text string, opts ...MessageOption) error {
-	return c.reply(text, mergeOpts(opts,
+	_, err := c.reply(text, mergeOpts(opts,
 		WithMarkdown(),
 		WithDisableWebPagePreview(true),
 	)...)
+	return err
 }
 
 // ReplyHTML reply to the current chat, text format is HTML.
 func (c *Context) ReplyHTML(text string, opts ...MessageOption) error {
-	return c.reply(text, mergeOpts(opts,
+	_, err := c.reply(text, mergeOpts(opts,
 		WithHTML(),
 		WithDisableWebPagePreview(true),
 	)...)
+	return err
+}
+
+// Reply reply to the current chat and return the sent message, which can be
+// edited by EditText or deleted by Delete later. The text longer than MaxMessageLength
+// is split into multiple messages and the last one is returned.
+func (c *Context) Reply(text string, opts ...MessageOption) (*tgbotapi.Message, error) {
+	return c.reply(text, opts...)
 }
 
-func (c *Context) reply(text string, opts ...MessageOption) error {
+// FormattedText is the text formatted for its parse mode, such as *format.Builder.
+type FormattedText interface {
+	ParseMode() string
+	String() string
+}
+
+// ReplyFormatted reply the formatted text to the current chat.
+func (c *Context) ReplyFormatted(text FormattedText, opts ...MessageOption) error {
+	_, err := c.reply(text.String(), mergeOpts(opts,
+		WithParseMode(text.ParseMode()),
+		WithDisableWebPagePreview(true),
+	)...)
+	return err
+}
+
+func (c *Context) reply(text string, opts ...MessageOption) (*tgbotapi.Message, error) {
 	msg := tgbotapi.NewMessage(0, text)
 	if chat := c.update.FromChat(); chat != nil {
 		msg.ChatID = chat.ID
@@ -115,7 +167,48 @@ func (c *Context) reply(text string, opts ...MessageOption) error {
 	for _, o := range opts {
 		o(&msg)
 	}
-	return c.SendReply(msg)
+
+	if utf16Len(msg.Text) <= MaxMessageLength {
+		sent, err := c.Send(msg)
+		if err != nil {
+			return nil, err
+		}
+		return &sent, nil
+	}
+
+	// the explicit entities are clipped to the parts with their offsets shifted,
+	// otherwise the entities of the parse mode are reopened in every part.
+	var parts []entityPart
+	if len(msg.Entities) > 0 {
+		parts = splitEntities(msg.Text, msg.Entities, MaxMessageLength)
+	} else {
+		for _, part := range splitMessage(msg.Text, msg.ParseMode, MaxMessageLength) {
+			parts = append(parts, entityPart{text: part})
+		}
+	}
+
+	// send the parts in order, only the first part replies to the message
+	// and only the last part has the reply markup.
+	var sent tgbotapi.Message
+	for i, part := range parts {
+		pm := msg
+		pm.Text = part.text
+		if len(msg.Entities) > 0 {
+			pm.Entities = part.entities
+		}
+		if i > 0 {
+			pm.ReplyToMessageID = 0
+		}
+		if i < len(parts)-1 {
+			pm.ReplyMarkup = nil
+		}
+
+		var err error
+		if sent, err = c.Send(pm); err != nil {
+			return nil, err
+		}
+	}
+	return &sent, nil
 }
 
 // SendReply send reply.
@@ -124,10 +217,54 @@ func (c *Context) SendReply(chat tgbotapi.Chattable) error {
 	return err
 }
 
+// Request send the chattable to telegram, it is retried by the retry policy if specified.
+// The callback query is not answered automatically after it is answered by Request.
+func (c *Context) Request(chat tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := c.request(chat)
+	if err == nil {
+		switch chat.(type) {
+		case tgbotapi.CallbackConfig, *tgbotapi.CallbackConfig:
+			c.callbackAnswered = true
+		}
+	}
+	return resp, err
+}
+
+func (c *Context) request(chat tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if c.bot == nil || c.bot.opts.retryPolicy == nil || !replayable(chat) {
+		return c.BotAPI.Request(chat)
+	}
+
+	var resp *tgbotapi.APIResponse
+	err := c.bot.opts.retryPolicy.do(c.Context, idempotent(chat), func() (err error) {
+		resp, err = c.BotAPI.Request(chat)
+		return err
+	})
+	return resp, err
+}
+
+// Send send the chattable to telegram and return the sent message.
+func (c *Context) Send(chat tgbotapi.Chattable) (tgbotapi.Message, error) {
+	resp, err := c.Request(chat)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	var message tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &message)
+	return message, err
+}
+
 // WithContext clone a Context for use in other goroutine.
 func (c *Context) WithContext(ctx context.Context) *Context {
 	nc := c.clone()
 	nc.Context = ctx
+
+	// clone the api to avoid changing the client of the shared api.
+	api := new(tgbotapi.BotAPI)
+	*api = *c.BotAPI
+	nc.BotAPI = api
+
 	switch cli := nc.BotAPI.Client.(type) {
 	case *client:
 		nc.BotAPI.Client = cli.withContext(nc.Context)
@@ -146,12 +283,27 @@ func (c *Context) clone() *Context {
 func (c *Context) reset() {
 	c.update = nil
 	c.Context = nil
+	c.params = nil
+	c.callbackAnswered = false
+	c.conversation = nil
+	c.session = nil
+	c.args = nil
+	c.argsInvalid = false
+	c.deepLinkPayload = ""
+	c.deepLinkPrefix = ""
 }
 
 func mergeOpts(opts []MessageOption, def ...MessageOption) []MessageOption {
 	return append(def, opts...)
 }
 
+// WithParseMode set parse mode.
+func WithParseMode(mode string) MessageOption {
+	return func(c *tgbotapi.MessageConfig) {
+		c.ParseMode = mode
+	}
+}
+
 // WithHTML set parse mode to html.
 func WithHTML() MessageOption {
 	return func(c *tgbotapi.MessageConfig) {
@@ -0,0 +1,367 @@
+package tgbot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoConversation is returned when change the conversation state but there is no conversation.
+var ErrNoConversation = errors.New("tgbot: no conversation")
+
+// ConversationKey is the key of a conversation, a user has at most one conversation in a chat.
+type ConversationKey struct {
+	ChatID int64
+	UserID int64
+}
+
+// ConversationState is the current state of a conversation.
+type ConversationState struct {
+	Conversation string
+	State        string
+
+	// ExpiresAt is the time the conversation expires, zero means never expires.
+	ExpiresAt time.Time
+}
+
+// ConversationStore stores the conversation states.
+type ConversationStore interface {
+	// Get return the state of the key, nil if there is no state.
+	Get(key ConversationKey) (*ConversationState, error)
+	Set(key ConversationKey, state *ConversationState) error
+	Delete(key ConversationKey) error
+}
+
+type memoryConversationStore struct {
+	mu     sync.RWMutex
+	states map[ConversationKey]ConversationState
+}
+
+// NewMemoryConversationStore new a ConversationStore that keep the states in memory.
+func NewMemoryConversationStore() ConversationStore {
+	return &memoryConversationStore{states: make(map[ConversationKey]ConversationState)}
+}
+
+func (s *memoryConversationStore) Get(key ConversationKey) (*ConversationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if state, ok := s.states[key]; ok {
+		return &state, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryConversationStore) Set(key ConversationKey, state *ConversationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = *state
+	return nil
+}
+
+func (s *memoryConversationStore) Delete(key ConversationKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// Conversation is a multi-step dialog made of named states, it is started by the
+// command with WithConversation, then the following messages and callback queries
+// of the user in the chat are handled by the handler of the current state, the callback
+// queries are answered automatically and the other registered commands are handled as usual.
+type Conversation struct {
+	name    string
+	initial string
+	states  map[string]Handler
+
+	// timeout is the idle timeout, the conversation ends if the user does not reply in time.
+	timeout time.Duration
+
+	cancelCommands []string
+	cancelHandler  Handler
+
+	// reentry whether the entry command restarts the conversation while it is active.
+	reentry bool
+
+	// captureCommands whether the registered commands are handled by the state handler.
+	captureCommands bool
+}
+
+type ConversationOption func(c *Conversation)
+
+// WithConversationTimeout set the idle timeout of the conversation.
+func WithConversationTimeout(d time.Duration) ConversationOption {
+	return func(c *Conversation) {
+		c.timeout = d
+	}
+}
+
+// WithConversationCancelCommands set the commands which cancel the conversation, default is "cancel".
+func WithConversationCancelCommands(commands ...string) ConversationOption {
+	return func(c *Conversation) {
+		c.cancelCommands = commands
+	}
+}
+
+// WithConversationCancelHandler set the handler called after the conversation is canceled.
+func WithConversationCancelHandler(h Handler) ConversationOption {
+	return func(c *Conversation) {
+		c.cancelHandler = h
+	}
+}
+
+// WithConversationReentry set whether the entry command restarts the active conversation,
+// if it is false, the entry command is handled by the current state handler.
+func WithConversationReentry(v bool) ConversationOption {
+	return func(c *Conversation) {
+		c.reentry = v
+	}
+}
+
+// WithConversationCaptureCommands set whether the other registered commands are handled by the
+// current state handler, by default they are handled as usual and the conversation stays active.
+func WithConversationCaptureCommands(v bool) ConversationOption {
+	return func(c *Conversation) {
+		c.captureCommands = v
+	}
+}
+
+// NewConversation new a conversation, initial is the state after the conversation started.
+func NewConversation(name, initial string, opts ...ConversationOption) *Conversation {
+	c := &Conversation{
+		name:           name,
+		initial:        initial,
+		states:         make(map[string]Handler),
+		cancelCommands: []string{"cancel"},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AddState add a state to the conversation, the handler is called when
+// the user sends a message in the state.
+func (c *Conversation) AddState(name string, h Handler) {
+	if h == nil {
+		panic("tgbot: conversation state handler must be non-nil")
+	}
+
+	if _, ok := c.states[name]; ok {
+		panic("duplicate conversation state: " + name)
+	}
+
+	c.states[name] = h
+}
+
+func (c *Conversation) Name() string {
+	return c.name
+}
+
+func (bot *Bot) isCancelCommand(conv *Conversation, command string) bool {
+	command = bot.normalizeCommand(command)
+	for _, cmd := range conv.cancelCommands {
+		if bot.normalizeCommand(cmd) == command {
+			return true
+		}
+	}
+	return false
+}
+
+// conversationSession is the conversation of the current update.
+type conversationSession struct {
+	key   ConversationKey
+	conv  *Conversation
+	state string
+	ended bool
+}
+
+func (bot *Bot) addConversation(conv *Conversation) {
+	if _, ok := conv.states[conv.initial]; !ok {
+		panic("tgbot: conversation " + conv.name + " initial state is not defined: " + conv.initial)
+	}
+
+	if bot.conversations == nil {
+		bot.conversations = make(map[string]*Conversation)
+	}
+
+	if c, ok := bot.conversations[conv.name]; ok && c != conv {
+		panic("duplicate conversation name: " + conv.name)
+	}
+
+	bot.conversations[conv.name] = conv
+
+	if bot.opts.conversationStore == nil {
+		bot.opts.conversationStore = NewMemoryConversationStore()
+	}
+}
+
+func conversationKeyOf(ctx *Context) (ConversationKey, bool) {
+	chat, user := ctx.FromChat(), ctx.SentFrom()
+	if chat == nil || user == nil {
+		return ConversationKey{}, false
+	}
+	return ConversationKey{ChatID: chat.ID, UserID: user.ID}, true
+}
+
+// conversationRoute return the handler of the active conversation of the user in the chat.
+func (bot *Bot) conversationRoute(ctx *Context) (Handler, bool) {
+	if ctx.update.Message == nil && ctx.update.CallbackQuery == nil {
+		return nil, false
+	}
+
+	key, ok := conversationKeyOf(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	store := bot.opts.conversationStore
+	st, err := store.Get(key)
+	if err != nil {
+		return func(ctx *Context) error {
+			return fmt.Errorf("failed to get conversation state, error: %w", err)
+		}, true
+	}
+	if st == nil {
+		return nil, false
+	}
+
+	var handler Handler
+	conv, ok := bot.conversations[st.Conversation]
+	if ok {
+		handler = conv.states[st.State]
+	}
+	if handler == nil || (!st.ExpiresAt.IsZero() && time.Now().After(st.ExpiresAt)) {
+		// the conversation is expired or no longer defined.
+		if err := store.Delete(key); err != nil {
+			bot.opts.errHandler(fmt.Errorf("failed to delete conversation state, error: %w", err))
+		}
+		return nil, false
+	}
+
+	ctx.conversation = &conversationSession{key: key, conv: conv, state: st.State}
+
+	if ctx.IsCommand() {
+		command := ctx.Command()
+		if bot.isCancelCommand(conv, command) {
+			return bot.cancelConversationHandler, true
+		}
+
+		// the entry command restarts the conversation if reentry is enabled, the other
+		// registered commands are handled as usual unless the conversation captures them.
+		if cmd, ok := bot.lookupCommand(command); ok {
+			entry := cmd.conversation == conv
+			if (entry && conv.reentry) || (!entry && !conv.captureCommands) {
+				ctx.conversation = nil
+				return nil, false
+			}
+		}
+	}
+
+	return bot.conversationHandler(handler), true
+}
+
+func (bot *Bot) cancelConversationHandler(ctx *Context) error {
+	ctx.conversation.ended = true
+	if err := bot.saveConversation(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	if h := ctx.conversation.conv.cancelHandler; h != nil {
+		err = h(ctx)
+	}
+	bot.answerCallback(ctx)
+	return err
+}
+
+// conversationHandler wraps the state handler to save the state after it is handled.
+func (bot *Bot) conversationHandler(h Handler) Handler {
+	return func(ctx *Context) error {
+		err := h(ctx)
+		if e := bot.saveConversation(ctx); e != nil && err == nil {
+			err = e
+		}
+		bot.answerCallback(ctx)
+		return err
+	}
+}
+
+// startConversation start the conversation after the entry command is handled successfully,
+// the conversation is not started if the command arguments are invalid.
+func (bot *Bot) startConversation(ctx *Context, conv *Conversation, h Handler) error {
+	key, ok := conversationKeyOf(ctx)
+	if !ok {
+		return h(ctx)
+	}
+
+	ctx.conversation = &conversationSession{key: key, conv: conv, state: conv.initial}
+	if err := h(ctx); err != nil {
+		return err
+	}
+
+	if ctx.argsInvalid {
+		ctx.conversation = nil
+		return nil
+	}
+	return bot.saveConversation(ctx)
+}
+
+func (bot *Bot) saveConversation(ctx *Context) error {
+	s := ctx.conversation
+	if s == nil {
+		return nil
+	}
+
+	store := bot.opts.conversationStore
+	if s.ended {
+		if err := store.Delete(s.key); err != nil {
+			return fmt.Errorf("failed to delete conversation state, error: %w", err)
+		}
+		return nil
+	}
+
+	st := &ConversationState{Conversation: s.conv.name, State: s.state}
+	if s.conv.timeout > 0 {
+		st.ExpiresAt = time.Now().Add(s.conv.timeout)
+	}
+	if err := store.Set(s.key, st); err != nil {
+		return fmt.Errorf("failed to save conversation state, error: %w", err)
+	}
+	return nil
+}
+
+// State return the current conversation state, empty if there is no conversation.
+func (c *Context) State() string {
+	if c.conversation == nil || c.conversation.ended {
+		return ""
+	}
+	return c.conversation.state
+}
+
+// SetState change the conversation state, the next message is handled by the handler of the state.
+func (c *Context) SetState(state string) error {
+	if c.conversation == nil {
+		return ErrNoConversation
+	}
+
+	if _, ok := c.conversation.conv.states[state]; !ok {
+		return fmt.Errorf("tgbot: conversation %s state is not defined: %s", c.conversation.conv.name, state)
+	}
+
+	c.conversation.state = state
+	c.conversation.ended = false
+	return nil
+}
+
+// EndConversation end the current conversation.
+func (c *Context) EndConversation() error {
+	if c.conversation == nil {
+		return ErrNoConversation
+	}
+
+	c.conversation.ended = true
+	return nil
+}
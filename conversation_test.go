@@ -0,0 +1,142 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestConversation(t *testing.T) {
+	var calls []string
+
+	conv := NewConversation("register", "name")
+	conv.AddState("name", func(ctx *Context) error {
+		calls = append(calls, "name:"+ctx.Message().Text)
+		return ctx.SetState("age")
+	})
+	conv.AddState("age", func(ctx *Context) error {
+		calls = append(calls, "age:"+ctx.Message().Text)
+		return ctx.EndConversation()
+	})
+
+	bot := NewBot(&tgbotapi.BotAPI{}, WithUpdatesHandler(func(ctx *Context) {
+		calls = append(calls, "updates:"+ctx.Message().Text)
+	}))
+	bot.AddCommands(NewCommand("register", "register", func(ctx *Context) error {
+		calls = append(calls, "register")
+		return nil
+	}, WithConversation(conv)))
+
+	send := func(text string) {
+		update := &tgbotapi.Update{Message: &tgbotapi.Message{Text: text, Chat: &tgbotapi.Chat{ID: 1}}}
+		if text[0] == '/' {
+			update = newCommandUpdate(text)
+		}
+		update.Message.From = &tgbotapi.User{ID: 2}
+		bot.makeUpdateHandler(update)()
+	}
+
+	for _, text := range []string{"/register", "bob", "/cancel", "hello", "/register", "bob", "18", "bye"} {
+		send(text)
+	}
+
+	except := []string{
+		"register", "name:bob", "updates:hello",
+		"register", "name:bob", "age:18", "updates:bye",
+	}
+	if !reflect.DeepEqual(calls, except) {
+		t.Errorf("calls except %v, got: %v", except, calls)
+	}
+}
+
+func TestConversationCommands(t *testing.T) {
+	var (
+		calls    []string
+		answered int
+	)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/answerCallbackQuery") {
+			answered++
+		}
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	})
+
+	conv := NewConversation("vote", "choose")
+	conv.AddState("choose", func(ctx *Context) error {
+		calls = append(calls, "choose")
+		return nil
+	})
+
+	bot := NewBot(api, WithCaseInsensitiveCommands(true))
+	noop := func(name string) Handler {
+		return func(ctx *Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	bot.AddCommands(
+		NewCommand("vote", "vote", noop("vote"), WithConversation(conv)),
+		NewCommand("status", "status", noop("status")),
+	)
+
+	from := &tgbotapi.User{ID: 2}
+	for _, text := range []string{"/vote", "/status", "/unknown"} {
+		update := newCommandUpdate(text)
+		update.Message.From = from
+		bot.makeUpdateHandler(update)()
+	}
+
+	bot.makeUpdateHandler(&tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "q", From: from, Data: "yes", Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}},
+	}})()
+
+	update := newCommandUpdate("/CANCEL")
+	update.Message.From = from
+	bot.makeUpdateHandler(update)()
+
+	except := []string{"vote", "status", "choose", "choose"}
+	if !reflect.DeepEqual(calls, except) {
+		t.Errorf("calls except %v, got: %v", except, calls)
+	}
+	if answered != 1 {
+		t.Errorf("answered callbacks except 1, got: %v", answered)
+	}
+
+	if st, _ := bot.opts.conversationStore.Get(ConversationKey{ChatID: 1, UserID: 2}); st != nil {
+		t.Errorf("conversation except canceled, got: %+v", st)
+	}
+}
+
+func TestConversationInvalidArgs(t *testing.T) {
+	var usages int
+	conv := NewConversation("go", "s")
+	conv.AddState("s", func(ctx *Context) error { return nil })
+
+	bot := NewBot(&tgbotapi.BotAPI{}, WithArgsErrorHandler(func(ctx *Context, err *ArgsError) error {
+		usages++
+		return nil
+	}))
+	bot.AddCommands(NewCommand("go", "go", func(ctx *Context) error { return nil },
+		WithArgs(Arg{Name: "n", Type: ArgInt, Required: true}),
+		WithConversation(conv),
+	))
+
+	for _, text := range []string{"/go notanint", "/go 1"} {
+		update := newCommandUpdate(text)
+		update.Message.From = &tgbotapi.User{ID: 2}
+		bot.makeUpdateHandler(update)()
+
+		st, _ := bot.opts.conversationStore.Get(ConversationKey{ChatID: 1, UserID: 2})
+		if started, except := st != nil, text == "/go 1"; started != except {
+			t.Errorf("%s conversation started except %v, got: %v", text, except, started)
+		}
+	}
+
+	if usages != 1 {
+		t.Errorf("usages except 1, got: %v", usages)
+	}
+}
@@ -0,0 +1,181 @@
+package tgbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// MaxDeepLinkPayloadLength is the maximum length of the start parameter of telegram.
+const MaxDeepLinkPayloadLength = 64
+
+// deepLinkSignatureSize is the size of the truncated HMAC-SHA256 of the signed payload.
+const deepLinkSignatureSize = 8
+
+var (
+	// ErrDeepLinkTooLong is returned when the encoded payload exceeds MaxDeepLinkPayloadLength.
+	ErrDeepLinkTooLong = errors.New("deep link payload exceeds 64 characters")
+
+	// ErrInvalidDeepLink is returned when the payload is malformed or the signature mismatches.
+	ErrInvalidDeepLink = errors.New("invalid deep link payload")
+)
+
+var deepLinkPayloadRe = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// deepLinkRoute is a deep link route registered by OnDeepLink.
+type deepLinkRoute struct {
+	prefix  string
+	handler Handler
+}
+
+// OnDeepLink register the handler for "/start <payload>" whose payload starts with the prefix,
+// the longest matching prefix wins, the payload after the prefix can be got by
+// Context.DeepLinkPayload and Context.DeepLinkData.
+// The roles, the cooldown and the middlewares of the registered start command also apply
+// to the deep link handlers, the start command without matching payload is handled as a normal command.
+func (bot *Bot) OnDeepLink(prefix string, h Handler) {
+	if !deepLinkPayloadRe.MatchString(prefix) {
+		panic("tgbot: deep link prefix must only contain A-Z, a-z, 0-9, _ and -: " + prefix)
+	}
+
+	for _, r := range bot.deepLinks {
+		if r.prefix == prefix {
+			panic("duplicate deep link prefix: " + prefix)
+		}
+	}
+
+	bot.deepLinks = append(bot.deepLinks, &deepLinkRoute{prefix: prefix, handler: h})
+	sort.SliceStable(bot.deepLinks, func(i, j int) bool {
+		return len(bot.deepLinks[i].prefix) > len(bot.deepLinks[j].prefix)
+	})
+}
+
+// deepLinkRoute return the handler of the deep link, ok is false if the update is not a routed deep link.
+func (bot *Bot) deepLinkRoute(ctx *Context) (Handler, bool) {
+	if !ctx.IsCommand() || bot.normalizeCommand(ctx.Command()) != bot.normalizeCommand("start") {
+		return nil, false
+	}
+
+	payload := ctx.CommandArgs()
+	if payload == "" {
+		return nil, false
+	}
+
+	for _, r := range bot.deepLinks {
+		if len(payload) >= len(r.prefix) && payload[:len(r.prefix)] == r.prefix {
+			ctx.deepLinkPayload = payload[len(r.prefix):]
+			ctx.deepLinkPrefix = r.prefix
+			if cmd, ok := bot.lookupCommand("start"); ok {
+				return bot.guardCommand(cmd, chain(r.handler, cmd.middlewares...)), true
+			}
+			return r.handler, true
+		}
+	}
+	return nil, false
+}
+
+// DeepLinkPayload return the payload of the deep link after the prefix.
+func (c *Context) DeepLinkPayload() string {
+	return c.deepLinkPayload
+}
+
+// DeepLinkData decode the payload of the deep link, the signature is verified against the
+// matched prefix and the data if the deep link secret is set.
+func (c *Context) DeepLinkData() ([]byte, error) {
+	return c.bot.decodeDeepLink(c.deepLinkPrefix, c.deepLinkPayload)
+}
+
+// EncodeDeepLinkPayload encode data with base64url, it returns ErrDeepLinkTooLong if the result is too long.
+func EncodeDeepLinkPayload(data []byte) (string, error) {
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	if len(payload) > MaxDeepLinkPayloadLength {
+		return "", ErrDeepLinkTooLong
+	}
+	return payload, nil
+}
+
+// DecodeDeepLinkPayload decode the base64url payload.
+func DecodeDeepLinkPayload(payload string) ([]byte, error) {
+	if len(payload) > MaxDeepLinkPayloadLength {
+		return nil, ErrDeepLinkTooLong
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeepLink, err)
+	}
+	return data, nil
+}
+
+// DeepLinkURL return the url which starts the bot with the prefix and the encoded data in private chat.
+func (bot *Bot) DeepLinkURL(prefix string, data []byte) (string, error) {
+	return bot.deepLinkURL("start", prefix, data)
+}
+
+// DeepLinkGroupURL return the url which adds the bot to a group with the prefix and the encoded data.
+func (bot *Bot) DeepLinkGroupURL(prefix string, data []byte) (string, error) {
+	return bot.deepLinkURL("startgroup", prefix, data)
+}
+
+func (bot *Bot) deepLinkURL(param, prefix string, data []byte) (string, error) {
+	if !deepLinkPayloadRe.MatchString(prefix) {
+		return "", fmt.Errorf("%w: prefix contains invalid characters", ErrInvalidDeepLink)
+	}
+
+	payload, err := bot.encodeDeepLink(prefix, data)
+	if err != nil {
+		return "", err
+	}
+
+	payload = prefix + payload
+	if len(payload) > MaxDeepLinkPayloadLength {
+		return "", ErrDeepLinkTooLong
+	}
+
+	return "https://t.me/" + url.PathEscape(bot.api.Self.UserName) + "?" + param + "=" + payload, nil
+}
+
+// encodeDeepLink encode data, the signature of the prefix and data is appended if the deep link secret is set,
+// so the payload can not be reused with another prefix.
+func (bot *Bot) encodeDeepLink(prefix string, data []byte) (string, error) {
+	if secret := bot.opts.deepLinkSecret; secret != nil {
+		data = append(data[:len(data):len(data)], deepLinkSignature(secret, prefix, data)...)
+	}
+	return EncodeDeepLinkPayload(data)
+}
+
+func (bot *Bot) decodeDeepLink(prefix, payload string) ([]byte, error) {
+	data, err := DecodeDeepLinkPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := bot.opts.deepLinkSecret
+	if secret == nil {
+		return data, nil
+	}
+
+	if len(data) < deepLinkSignatureSize {
+		return nil, ErrInvalidDeepLink
+	}
+
+	data, sig := data[:len(data)-deepLinkSignatureSize], data[len(data)-deepLinkSignatureSize:]
+	if !hmac.Equal(sig, deepLinkSignature(secret, prefix, data)) {
+		return nil, ErrInvalidDeepLink
+	}
+	return data, nil
+}
+
+func deepLinkSignature(secret []byte, prefix string, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	// the prefix never contains the zero byte, so the separator keeps prefix and data unambiguous.
+	mac.Write([]byte(prefix))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return mac.Sum(nil)[:deepLinkSignatureSize]
+}
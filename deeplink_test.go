@@ -0,0 +1,103 @@
+package tgbot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestDeepLinkRoute(t *testing.T) {
+	var got []string
+	record := func(name string) Handler {
+		return func(ctx *Context) error {
+			got = append(got, name+":"+ctx.DeepLinkPayload())
+			return nil
+		}
+	}
+
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.AddCommands(NewCommand("start", "start", record("start")))
+	bot.OnDeepLink("ref_", record("ref"))
+	bot.OnDeepLink("ref_vip_", record("vip"))
+
+	for _, text := range []string{"/start ref_42", "/start ref_vip_7", "/start other", "/start"} {
+		bot.makeUpdateHandler(newCommandUpdate(text))()
+	}
+
+	except := []string{"ref:42", "vip:7", "start:", "start:"}
+	if strings.Join(got, ",") != strings.Join(except, ",") {
+		t.Errorf("routes except %v, got: %v", except, got)
+	}
+}
+
+func TestDeepLinkCommandPolicy(t *testing.T) {
+	var calls, forbidden int
+	bot := NewBot(&tgbotapi.BotAPI{}, WithForbiddenHandler(func(ctx *Context) error {
+		forbidden++
+		return nil
+	}))
+	bot.AddCommands(NewCommand("start", "start", func(ctx *Context) error { return nil },
+		WithRoles(RoleUsers(1)),
+		WithMiddlewares(func(next Handler) Handler {
+			return func(ctx *Context) error {
+				calls++
+				return next(ctx)
+			}
+		}),
+	))
+	bot.OnDeepLink("ref_", func(ctx *Context) error {
+		calls++
+		return nil
+	})
+
+	for _, id := range []int64{1, 2} {
+		update := newCommandUpdate("/start ref_42")
+		update.Message.From = &tgbotapi.User{ID: id}
+		bot.makeUpdateHandler(update)()
+	}
+
+	if calls != 2 || forbidden != 1 {
+		t.Errorf("calls and forbidden except 2 and 1, got: %d and %d", calls, forbidden)
+	}
+}
+
+func TestDeepLinkURL(t *testing.T) {
+	bot := NewBot(&tgbotapi.BotAPI{Self: tgbotapi.User{UserName: "mybot"}}, WithDeepLinkSecret([]byte("secret")))
+
+	link, err := bot.DeepLinkGroupURL("inv_", []byte("team:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, "https://t.me/mybot?startgroup=inv_") {
+		t.Fatalf("url except startgroup link, got: %s", link)
+	}
+
+	payload := strings.TrimPrefix(link, "https://t.me/mybot?startgroup=inv_")
+	data, err := bot.decodeDeepLink("inv_", payload)
+	if err != nil || string(data) != "team:1" {
+		t.Errorf("decode except team:1, got: %q, %v", data, err)
+	}
+
+	raw, err := DecodeDeepLinkPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[0] ^= 1
+	tampered, err := EncodeDeepLinkPayload(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bot.decodeDeepLink("inv_", tampered); !errors.Is(err, ErrInvalidDeepLink) {
+		t.Errorf("tampered data except ErrInvalidDeepLink, got: %v", err)
+	}
+
+	if _, err := bot.decodeDeepLink("in", payload); !errors.Is(err, ErrInvalidDeepLink) {
+		t.Errorf("other prefix except ErrInvalidDeepLink, got: %v", err)
+	}
+
+	if _, err := bot.DeepLinkURL("x", make([]byte, 48)); err != ErrDeepLinkTooLong {
+		t.Errorf("long payload except ErrDeepLinkTooLong, got: %v", err)
+	}
+}
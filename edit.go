@@ -0,0 +1,155 @@
+package tgbot
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// editTarget return the message to edit, msg nil means the message of the current callback query,
+// which is an inline message if the callback query comes from an inline message.
+func (c *Context) editTarget(msg *tgbotapi.Message) tgbotapi.BaseEdit {
+	if msg != nil {
+		return tgbotapi.BaseEdit{ChatID: msg.Chat.ID, MessageID: msg.MessageID}
+	}
+
+	if query := c.CallbackQuery(); query != nil {
+		if query.Message != nil {
+			return tgbotapi.BaseEdit{ChatID: query.Message.Chat.ID, MessageID: query.Message.MessageID}
+		}
+		return tgbotapi.BaseEdit{InlineMessageID: query.InlineMessageID}
+	}
+	return tgbotapi.BaseEdit{}
+}
+
+// EditText edit the text of the message, msg nil means the message of the current callback query.
+// The parse mode, entities, web page preview and inline keyboard of opts are applied.
+func (c *Context) EditText(msg *tgbotapi.Message, text string, opts ...MessageOption) error {
+	var mc tgbotapi.MessageConfig
+	for _, o := range opts {
+		o(&mc)
+	}
+
+	config := tgbotapi.EditMessageTextConfig{
+		BaseEdit:              c.editTarget(msg),
+		Text:                  text,
+		ParseMode:             mc.ParseMode,
+		Entities:              mc.Entities,
+		DisableWebPagePreview: mc.DisableWebPagePreview,
+	}
+	if markup, ok := mc.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+		config.ReplyMarkup = &markup
+	}
+	return c.SendReply(config)
+}
+
+// EditMarkup replace the inline keyboard of the message, msg nil means the message of the current callback query.
+func (c *Context) EditMarkup(msg *tgbotapi.Message, markup tgbotapi.InlineKeyboardMarkup) error {
+	config := tgbotapi.EditMessageReplyMarkupConfig{BaseEdit: c.editTarget(msg)}
+	config.ReplyMarkup = &markup
+	return c.SendReply(config)
+}
+
+// EditCaption edit the caption of the message, msg nil means the message of the current callback query.
+// The caption parse mode, caption entities and inline keyboard of opts are applied.
+func (c *Context) EditCaption(msg *tgbotapi.Message, caption string, opts ...MediaOption) error {
+	o := &mediaOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	config := tgbotapi.EditMessageCaptionConfig{
+		BaseEdit:        c.editTarget(msg),
+		Caption:         caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	}
+	if markup, ok := o.replyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+		config.ReplyMarkup = &markup
+	}
+	return c.SendReply(config)
+}
+
+// Delete delete the message, msg nil means the message of the current update.
+func (c *Context) Delete(msg *tgbotapi.Message) error {
+	if msg == nil {
+		msg = c.Message()
+	}
+	if msg == nil {
+		return nil
+	}
+	return c.SendReply(tgbotapi.NewDeleteMessage(msg.Chat.ID, msg.MessageID))
+}
+
+// DefaultProgressInterval is the minimum interval between the updates of the progress message.
+var DefaultProgressInterval = time.Second
+
+// Progress is a message which shows the progress of a long-running handler,
+// the updates are throttled to avoid hitting the rate limits of editing.
+type Progress struct {
+	ctx  *Context
+	msg  *tgbotapi.Message
+	opts []MessageOption
+
+	mu        sync.Mutex
+	text      string
+	updatedAt time.Time
+}
+
+// Progress reply the progress message to the current chat, e.g.
+//
+//	p, err := ctx.Progress("processing...")
+//	...
+//	p.Update("50%")
+//	...
+//	p.Done("done")
+//
+// It is safe to update the progress from multiple goroutines.
+func (c *Context) Progress(text string, opts ...MessageOption) (*Progress, error) {
+	msg, err := c.reply(text, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Progress{ctx: c, msg: msg, opts: opts, text: text, updatedAt: time.Now()}, nil
+}
+
+// Message return the progress message.
+func (p *Progress) Message() *tgbotapi.Message {
+	return p.msg
+}
+
+// Update edit the progress message, the update is dropped if the text is unchanged
+// or the last update is within DefaultProgressInterval.
+func (p *Progress) Update(text string) error {
+	return p.edit(text, false)
+}
+
+// Done edit the progress message to the final text regardless of the interval.
+func (p *Progress) Done(text string) error {
+	return p.edit(text, true)
+}
+
+// Delete delete the progress message.
+func (p *Progress) Delete() error {
+	return p.ctx.Delete(p.msg)
+}
+
+func (p *Progress) edit(text string, force bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if text == p.text || (!force && now.Sub(p.updatedAt) < DefaultProgressInterval) {
+		return nil
+	}
+
+	if err := p.ctx.EditText(p.msg, text, p.opts...); err != nil {
+		return err
+	}
+
+	p.text = text
+	p.updatedAt = now
+	return nil
+}
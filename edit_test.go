@@ -0,0 +1,45 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var calls []string
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		calls = append(calls, method+":"+r.Form.Get("text"))
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 5, "chat": {"id": 1}}}`)
+	})
+
+	ctx := &Context{BotAPI: api, update: newCommandUpdate("/job")}
+
+	p, err := ctx.Progress("processing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Message().MessageID != 5 {
+		t.Errorf("message id except 5, got: %v", p.Message().MessageID)
+	}
+
+	for _, text := range []string{"10%", "20%"} {
+		if err := p.Update(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Done("done"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	except := []string{"sendMessage:processing", "editMessageText:done", "deleteMessage:"}
+	if strings.Join(calls, ",") != strings.Join(except, ",") {
+		t.Errorf("calls except %v, got: %v", except, calls)
+	}
+}
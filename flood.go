@@ -0,0 +1,207 @@
+package tgbot
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CounterStore counts the events in fixed windows.
+type CounterStore interface {
+	// Incr increase the counter of key and return the count in the current window
+	// and the remaining duration of the window, the window starts at the first Incr.
+	Incr(key string, window time.Duration) (count int, reset time.Duration, err error)
+}
+
+type memoryCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+type memoryCounterStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+// NewMemoryCounterStore new a CounterStore that keep the counters in memory.
+func NewMemoryCounterStore() CounterStore {
+	return &memoryCounterStore{
+		counters:  make(map[string]*memoryCounter),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memoryCounterStore) Incr(key string, window time.Duration) (int, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	// sweep the expired counters at most once per minute.
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.lastSweep = now
+		for k, c := range s.counters {
+			if !now.Before(c.expiresAt) {
+				delete(s.counters, k)
+			}
+		}
+	}
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = &memoryCounter{expiresAt: now.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, c.expiresAt.Sub(now), nil
+}
+
+// LimitHandler handle the update that exceeds the limit, wait is the duration until the limit resets.
+type LimitHandler func(ctx *Context, wait time.Duration) error
+
+// CooldownScope is the scope which the cooldown is counted in.
+type CooldownScope int
+
+const (
+	// CooldownPerUser counts the invocations of every user.
+	CooldownPerUser CooldownScope = iota
+
+	// CooldownPerChat counts the invocations of every chat.
+	CooldownPerChat
+
+	// CooldownGlobal counts the invocations of everyone.
+	CooldownGlobal
+)
+
+type cooldown struct {
+	limit  int
+	window time.Duration
+	scope  CooldownScope
+}
+
+// WithCooldown limit the command to n invocations per window in the scope.
+func WithCooldown(n int, window time.Duration, scope CooldownScope) CommandOption {
+	return func(cmd *Command) {
+		cmd.cooldown = &cooldown{limit: n, window: window, scope: scope}
+	}
+}
+
+// cooldownKey return the counter key of the command, ok is false if the update is out of the scope.
+func cooldownKey(ctx *Context, cmd *Command) (string, bool) {
+	key := "cooldown:" + cmd.Name
+	switch cmd.cooldown.scope {
+	case CooldownPerUser:
+		user := ctx.SentFrom()
+		if user == nil {
+			return "", false
+		}
+		return key + ":user:" + strconv.FormatInt(user.ID, 10), true
+
+	case CooldownPerChat:
+		chat := ctx.FromChat()
+		if chat == nil {
+			return "", false
+		}
+		return key + ":chat:" + strconv.FormatInt(chat.ID, 10), true
+
+	default:
+		return key, true
+	}
+}
+
+// checkCooldown report whether the command is allowed to run, wait is the duration until the cooldown resets.
+func (bot *Bot) checkCooldown(ctx *Context, cmd *Command) (allowed bool, wait time.Duration, err error) {
+	key, ok := cooldownKey(ctx, cmd)
+	if !ok {
+		return true, 0, nil
+	}
+
+	count, reset, err := bot.opts.counterStore.Incr(key, cmd.cooldown.window)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to increase cooldown counter, error: %w", err)
+	}
+	return count <= cmd.cooldown.limit, reset, nil
+}
+
+func (bot *Bot) cooldownHandler(ctx *Context, wait time.Duration) error {
+	if bot.opts.cooldownHandler != nil {
+		return bot.opts.cooldownHandler(ctx, wait)
+	}
+
+	return ctx.ReplyText(fmt.Sprintf("Please wait %s before using /%s again.", wait.Round(time.Second), ctx.Command()))
+}
+
+type antiFloodOptions struct {
+	store    CounterStore
+	handler  LimitHandler
+	throttle bool
+}
+
+// AntiFloodOption is the option of AntiFlood.
+type AntiFloodOption func(o *antiFloodOptions)
+
+// WithAntiFloodStore set the counter store, default is a memory counter store.
+func WithAntiFloodStore(store CounterStore) AntiFloodOption {
+	return func(o *antiFloodOptions) {
+		o.store = store
+	}
+}
+
+// WithAntiFloodHandler set the handler that is called on the first update exceeding
+// the limit in the window, the exceeding updates are ignored silently if it is nil.
+func WithAntiFloodHandler(h LimitHandler) AntiFloodOption {
+	return func(o *antiFloodOptions) {
+		o.handler = h
+	}
+}
+
+// WithAntiFloodThrottle delay the exceeding updates until the window resets instead of dropping them.
+func WithAntiFloodThrottle(v bool) AntiFloodOption {
+	return func(o *antiFloodOptions) {
+		o.throttle = v
+	}
+}
+
+// AntiFlood return a middleware that drops the updates of the users who send
+// more than limit updates per window, the updates without sender are not limited.
+func AntiFlood(limit int, window time.Duration, opts ...AntiFloodOption) Middleware {
+	o := &antiFloodOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryCounterStore()
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			user := ctx.SentFrom()
+			if user == nil {
+				return next(ctx)
+			}
+
+			key := "flood:" + strconv.FormatInt(user.ID, 10)
+			for {
+				count, reset, err := o.store.Incr(key, window)
+				if err != nil {
+					return fmt.Errorf("failed to increase flood counter, error: %w", err)
+				}
+				if count <= limit {
+					return next(ctx)
+				}
+
+				if o.handler != nil && count == limit+1 {
+					if err := o.handler(ctx, reset); err != nil {
+						return err
+					}
+				}
+
+				if !o.throttle || !sleepContext(ctx, reset) {
+					return nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tgbot
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestMemoryCounterStore(t *testing.T) {
+	store := NewMemoryCounterStore()
+	for i := 1; i <= 3; i++ {
+		count, reset, err := store.Incr("k", 20*time.Millisecond)
+		if err != nil || count != i || reset <= 0 {
+			t.Fatalf("incr except %d, got: %d, %v, %v", i, count, reset, err)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if count, _, _ := store.Incr("k", time.Minute); count != 1 {
+		t.Errorf("incr after window except 1, got: %v", count)
+	}
+}
+
+func TestCommandCooldown(t *testing.T) {
+	var calls, limited int
+	bot := NewBot(&tgbotapi.BotAPI{}, WithCooldownHandler(func(ctx *Context, wait time.Duration) error {
+		limited++
+		return nil
+	}))
+	bot.AddCommands(NewCommand("report", "report", func(ctx *Context) error {
+		calls++
+		return nil
+	}, WithCooldown(2, time.Minute, CooldownPerUser)))
+
+	for _, userID := range []int64{1, 1, 1, 2} {
+		update := newCommandUpdate("/report")
+		update.Message.From = &tgbotapi.User{ID: userID}
+		bot.makeUpdateHandler(update)()
+	}
+
+	if calls != 3 || limited != 1 {
+		t.Errorf("calls and limited except 3 and 1, got: %v and %v", calls, limited)
+	}
+}
+
+func TestAntiFlood(t *testing.T) {
+	var calls, warned int
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.Use(AntiFlood(2, time.Minute, WithAntiFloodHandler(func(ctx *Context, wait time.Duration) error {
+		warned++
+		return nil
+	})))
+	bot.AddCommands(NewCommand("ping", "ping", func(ctx *Context) error {
+		calls++
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		update := newCommandUpdate("/ping")
+		update.Message.From = &tgbotapi.User{ID: 1}
+		bot.makeUpdateHandler(update)()
+	}
+
+	if calls != 2 || warned != 1 {
+		t.Errorf("calls and warned except 2 and 1, got: %v and %v", calls, warned)
+	}
+}
@@ -0,0 +1,241 @@
+// Package format builds the message text of telegram which is escaped correctly
+// for the HTML or MarkdownV2 parse mode.
+//
+//	text := format.MarkdownV2().
+//		Bold("Build").Text(" finished in 1.5s.").Line().
+//		Pre("go", "fmt.Println(\"ok\")")
+//	ctx.ReplyFormatted(text)
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The parse modes supported by the Builder, they are the same as tgbotapi.ModeHTML and tgbotapi.ModeMarkdownV2.
+const (
+	ModeHTML       = "HTML"
+	ModeMarkdownV2 = "MarkdownV2"
+)
+
+// ModeMarkdown is the legacy Markdown parse mode, it is only supported by Escape.
+const ModeMarkdown = "Markdown"
+
+var (
+	htmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+	markdownReplacer = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+
+	legacyMarkdownReplacer = strings.NewReplacer("_", `\_`, "*", `\*`, "[", `\[`, "`", "\\`")
+
+	markdownCodeReplacer = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownURLReplacer  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+// Escape escape the special characters of the parse mode, s is returned as is for other parse modes.
+func Escape(mode, s string) string {
+	switch mode {
+	case ModeHTML:
+		return htmlReplacer.Replace(s)
+	case ModeMarkdownV2:
+		return markdownReplacer.Replace(s)
+	case ModeMarkdown:
+		return legacyMarkdownReplacer.Replace(s)
+	default:
+		return s
+	}
+}
+
+// Builder builds the formatted text, all the methods escape their arguments except Raw.
+type Builder struct {
+	mode string
+	b    strings.Builder
+}
+
+// New new a Builder of the parse mode, mode is ModeHTML or ModeMarkdownV2.
+func New(mode string) *Builder {
+	if mode != ModeHTML && mode != ModeMarkdownV2 {
+		panic("format: parse mode must be HTML or MarkdownV2: " + mode)
+	}
+	return &Builder{mode: mode}
+}
+
+// HTML new a Builder of the HTML parse mode.
+func HTML() *Builder {
+	return New(ModeHTML)
+}
+
+// MarkdownV2 new a Builder of the MarkdownV2 parse mode.
+func MarkdownV2() *Builder {
+	return New(ModeMarkdownV2)
+}
+
+// ParseMode return the parse mode of the text.
+func (b *Builder) ParseMode() string {
+	return b.mode
+}
+
+// String return the formatted text.
+func (b *Builder) String() string {
+	return b.b.String()
+}
+
+// Len return the length of the formatted text in bytes.
+func (b *Builder) Len() int {
+	return b.b.Len()
+}
+
+func (b *Builder) html() bool {
+	return b.mode == ModeHTML
+}
+
+func (b *Builder) escape(s string) string {
+	return Escape(b.mode, s)
+}
+
+// wrap write the escaped s between the HTML tag or the MarkdownV2 marker.
+func (b *Builder) wrap(tag, marker, s string) *Builder {
+	if b.html() {
+		b.b.WriteString("<" + tag + ">" + b.escape(s) + "</" + tag + ">")
+	} else {
+		b.b.WriteString(marker + b.escape(s) + marker)
+	}
+	return b
+}
+
+// Raw write s as is, s must be valid for the parse mode.
+func (b *Builder) Raw(s string) *Builder {
+	b.b.WriteString(s)
+	return b
+}
+
+// Text write the plain text.
+func (b *Builder) Text(s string) *Builder {
+	b.b.WriteString(b.escape(s))
+	return b
+}
+
+// Textf write the plain text formatted by fmt.Sprintf.
+func (b *Builder) Textf(format string, args ...interface{}) *Builder {
+	return b.Text(fmt.Sprintf(format, args...))
+}
+
+// Line write a line break.
+func (b *Builder) Line() *Builder {
+	b.b.WriteByte('\n')
+	return b
+}
+
+// Bold write the bold text.
+func (b *Builder) Bold(s string) *Builder {
+	return b.wrap("b", "*", s)
+}
+
+// Italic write the italic text.
+func (b *Builder) Italic(s string) *Builder {
+	return b.wrap("i", "_", s)
+}
+
+// Underline write the underlined text.
+func (b *Builder) Underline(s string) *Builder {
+	return b.wrap("u", "__", s)
+}
+
+// Strike write the strikethrough text.
+func (b *Builder) Strike(s string) *Builder {
+	return b.wrap("s", "~", s)
+}
+
+// Spoiler write the spoiler text.
+func (b *Builder) Spoiler(s string) *Builder {
+	if b.html() {
+		b.b.WriteString(`<span class="tg-spoiler">` + b.escape(s) + "</span>")
+	} else {
+		b.b.WriteString("||" + b.escape(s) + "||")
+	}
+	return b
+}
+
+// Code write the inline code.
+func (b *Builder) Code(s string) *Builder {
+	if b.html() {
+		b.b.WriteString("<code>" + b.escape(s) + "</code>")
+	} else {
+		b.b.WriteString("`" + markdownCodeReplacer.Replace(s) + "`")
+	}
+	return b
+}
+
+// Pre write the pre-formatted code block, language is optional, the characters other than
+// letters, digits and "+#-_." are stripped from it since they may break the code block.
+func (b *Builder) Pre(language, code string) *Builder {
+	language = languageTag(language)
+	if b.html() {
+		if language == "" {
+			b.b.WriteString("<pre>" + b.escape(code) + "</pre>")
+		} else {
+			b.b.WriteString(`<pre><code class="language-` + b.escape(language) + `">` + b.escape(code) + "</code></pre>")
+		}
+		return b
+	}
+
+	b.b.WriteString("```" + language + "\n" + markdownCodeReplacer.Replace(code) + "\n```")
+	return b
+}
+
+// languageTag strip the characters which are not allowed in the language of the code block.
+func languageTag(language string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("+#-_.", r):
+			return r
+		default:
+			return -1
+		}
+	}, language)
+}
+
+// Link write the text linked to the url.
+func (b *Builder) Link(text, url string) *Builder {
+	if b.html() {
+		b.b.WriteString(`<a href="` + b.escape(url) + `">` + b.escape(text) + "</a>")
+	} else {
+		b.b.WriteString("[" + b.escape(text) + "](" + markdownURLReplacer.Replace(url) + ")")
+	}
+	return b
+}
+
+// Mention write the text which mentions the user of the id, it works even if the user has no username.
+func (b *Builder) Mention(text string, userID int64) *Builder {
+	return b.Link(text, "tg://user?id="+strconv.FormatInt(userID, 10))
+}
+
+// Blockquote write the quotation, the lines of s are quoted. In MarkdownV2 the quotation
+// starts on a new line and ends with a line break, so the text around it is not quoted.
+func (b *Builder) Blockquote(s string) *Builder {
+	if b.html() {
+		b.b.WriteString("<blockquote>" + b.escape(s) + "</blockquote>")
+		return b
+	}
+
+	if text := b.b.String(); text != "" && !strings.HasSuffix(text, "\n") {
+		b.b.WriteByte('\n')
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			b.b.WriteByte('\n')
+		}
+		b.b.WriteString(">" + b.escape(line))
+	}
+	b.b.WriteByte('\n')
+	return b
+}
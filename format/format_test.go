@@ -0,0 +1,60 @@
+package format
+
+import "testing"
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   *Builder
+		except string
+	}{
+		{
+			name:   "markdown",
+			text:   MarkdownV2().Bold("v1.2").Text(" - done!").Line().Code("a`b").Link("docs (new)", "https://x.y/(1)"),
+			except: "*v1\\.2* \\- done\\!\n`a\\`b`[docs \\(new\\)](https://x.y/(1\\))",
+		},
+		{
+			name:   "html",
+			text:   HTML().Bold("a<b").Text(" & ").Mention("Tom", 42).Pre("go", "x := 1 < 2"),
+			except: `<b>a&lt;b</b> &amp; <a href="tg://user?id=42">Tom</a><pre><code class="language-go">x := 1 &lt; 2</code></pre>`,
+		},
+		{
+			name:   "quote",
+			text:   MarkdownV2().Text("x").Blockquote("a.\nb").Spoiler("c"),
+			except: "x\n>a\\.\n>b\n||c||",
+		},
+		{
+			name:   "quote after line",
+			text:   MarkdownV2().Text("x").Line().Blockquote("a"),
+			except: "x\n>a\n",
+		},
+		{
+			name:   "pre language",
+			text:   MarkdownV2().Pre("go`\nx", "y").Pre("c++", "z"),
+			except: "```gox\ny\n``````c++\nz\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		if text := tt.text.String(); text != tt.except {
+			t.Errorf("%s except %q, got: %q", tt.name, tt.except, text)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		mode, s, except string
+	}{
+		{mode: ModeMarkdown, s: "a_b*c[d]`e`.", except: "a\\_b\\*c\\[d]\\`e\\`."},
+		{mode: ModeMarkdownV2, s: "a_b.", except: "a\\_b\\."},
+		{mode: ModeHTML, s: "a<b", except: "a&lt;b"},
+		{mode: "", s: "a_b", except: "a_b"},
+	}
+
+	for _, tt := range tests {
+		if s := Escape(tt.mode, tt.s); s != tt.except {
+			t.Errorf("escape %q in %q except %q, got: %q", tt.s, tt.mode, tt.except, s)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package tgbot
+
+import (
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/imzhongqi/go-tgbot/format"
+)
+
+// WithLongDescription set the long description of the command, it is shown by "/help <command>".
+func WithLongDescription(desc string) CommandOption {
+	return func(cmd *Command) {
+		cmd.longDescription = desc
+	}
+}
+
+// WithUsage set the usage of the command, it overrides the usage generated from the argument schema.
+func WithUsage(usage string) CommandOption {
+	return func(cmd *Command) {
+		cmd.usage = usage
+	}
+}
+
+func (c *Command) LongDescription() string {
+	return c.longDescription
+}
+
+// NewHelpCommand new the built-in help command, it lists the commands visible in
+// the current chat as the Telegram commands menu resolves the scopes and the user
+// language, and "/help <command>" shows the details of the command.
+// parseMode is tgbotapi.ModeHTML or tgbotapi.ModeMarkdownV2.
+func NewHelpCommand(parseMode string, opts ...CommandOption) *Command {
+	if parseMode != tgbotapi.ModeHTML && parseMode != tgbotapi.ModeMarkdownV2 {
+		panic("tgbot: help parse mode must be HTML or MarkdownV2")
+	}
+
+	h := &helpRenderer{parseMode: parseMode}
+	return NewCommand("help", "Show the help", h.handle, append([]CommandOption{
+		WithArgs(Arg{Name: "command", Type: ArgString, Description: "the command to show"}),
+	}, opts...)...)
+}
+
+type helpRenderer struct {
+	parseMode string
+}
+
+func (h *helpRenderer) handle(ctx *Context) error {
+	visible := ctx.bot.visibleCommands(ctx)
+
+	var text string
+	if name := strings.TrimPrefix(ctx.ArgString("command"), "/"); name != "" {
+		cmd, ok := ctx.bot.lookupCommand(name)
+		if ok {
+			cmd, ok = visible[cmd.Name]
+		}
+		if !ok {
+			_, err := ctx.reply(h.escape("Unrecognized command: /"+name), WithParseMode(h.parseMode))
+			return err
+		}
+		text = h.renderCommand(cmd)
+	} else {
+		text = h.renderList(visible)
+	}
+
+	_, err := ctx.reply(text, WithParseMode(h.parseMode), WithDisableWebPagePreview(true))
+	return err
+}
+
+func (h *helpRenderer) renderList(commands map[string]*Command) string {
+	names := make([]string, 0, len(commands))
+	for name, cmd := range commands {
+		if !cmd.hide {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(h.bold("Commands"))
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(h.escape("/" + name + " - " + commands[name].Description))
+	}
+	return b.String()
+}
+
+func (h *helpRenderer) renderCommand(cmd *Command) string {
+	var b strings.Builder
+	b.WriteString(h.bold("/" + cmd.Name))
+	b.WriteString(h.escape(" - " + cmd.Description))
+
+	if len(cmd.aliases) > 0 {
+		b.WriteString("\n")
+		b.WriteString(h.escape("Aliases: /" + strings.Join(cmd.aliases, ", /")))
+	}
+
+	if cmd.longDescription != "" {
+		b.WriteString("\n\n")
+		b.WriteString(h.escape(cmd.longDescription))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(h.escape("Usage: "))
+	b.WriteString(h.code(cmd.Usage()))
+
+	var args []string
+	for _, arg := range cmd.args {
+		s := arg.Name + " (" + arg.placeholder()
+		if arg.Required {
+			s += ", required"
+		}
+		if arg.Default != "" {
+			s += ", default " + arg.Default
+		}
+		s += ")"
+		if arg.Description != "" {
+			s += " - " + arg.Description
+		}
+		args = append(args, h.escape("• "+s))
+	}
+	if len(args) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(h.bold("Arguments"))
+		b.WriteString("\n")
+		b.WriteString(strings.Join(args, "\n"))
+	}
+
+	return b.String()
+}
+
+func (h *helpRenderer) escape(s string) string {
+	return format.Escape(h.parseMode, s)
+}
+
+func (h *helpRenderer) bold(s string) string {
+	return format.New(h.parseMode).Bold(s).String()
+}
+
+func (h *helpRenderer) code(s string) string {
+	return format.New(h.parseMode).Code(s).String()
+}
+
+// visibleCommands return the commands visible in the chat of the context by their scopes.
+func (bot *Bot) visibleCommands(ctx *Context) map[string]*Command {
+	var (
+		chat = ctx.FromChat()
+		user = ctx.SentFrom()
+
+		admin    bool
+		adminSet bool
+	)
+
+	// isAdmin is loaded lazily from the administrators cache since it may require a request.
+	isAdmin := func() bool {
+		if !adminSet {
+			adminSet = true
+			member, ok, err := bot.chatAdmin(ctx)
+			admin = err == nil && ok && (member.IsAdministrator() || member.IsCreator())
+		}
+		return admin
+	}
+
+	// the menu commands are resolved like Telegram, the first scope in order which has commands
+	// for the language of the user or for all languages is used, the hidden commands are not in
+	// the menu, so they are visible in any of their scopes.
+	visible := make(map[string]*Command)
+	for _, typ := range scopeOrder(chat) {
+		for _, withLang := range []bool{true, false} {
+			for name, cmd := range bot.commands {
+				if !cmd.hide && scopesVisible(cmd, chat, user, isAdmin, func(scope CommandScope) bool {
+					return scopeType(scope) == typ && (scope.LanguageCode() != "") == withLang
+				}) {
+					visible[name] = cmd
+				}
+			}
+			if len(visible) > 0 {
+				break
+			}
+		}
+		if len(visible) > 0 {
+			break
+		}
+	}
+
+	for name, cmd := range bot.commands {
+		if cmd.hide && scopesVisible(cmd, chat, user, isAdmin, nil) {
+			visible[name] = cmd
+		}
+	}
+	return visible
+}
+
+// scopeOrder return the scope types in the order Telegram resolves the commands menu of the chat.
+func scopeOrder(chat *tgbotapi.Chat) []string {
+	switch {
+	case chat != nil && chat.IsPrivate():
+		return []string{ScopeTypeChat, ScopeTypeAllPrivateChats, ScopeTypeDefault}
+	case chat != nil && (chat.IsGroup() || chat.IsSuperGroup()):
+		return []string{
+			ScopeTypeChatMember, ScopeTypeChatAdministrators, ScopeTypeChat,
+			ScopeTypeAllChatAdministrators, ScopeTypeAllGroupChats, ScopeTypeDefault,
+		}
+	default:
+		return []string{ScopeTypeDefault}
+	}
+}
+
+// scopeType return the type of the scope, the commands without scope are set in the default scope.
+func scopeType(scope CommandScope) string {
+	if scope == noScope {
+		return ScopeTypeDefault
+	}
+	return scope.Type()
+}
+
+// scopesVisible report whether the command is visible in any of its scopes which match the filter.
+func scopesVisible(cmd *Command, chat *tgbotapi.Chat, user *tgbotapi.User, isAdmin func() bool, filter func(scope CommandScope) bool) bool {
+	scopes := cmd.scopes
+	if len(scopes) == 0 {
+		scopes = []CommandScope{noScope}
+	}
+
+	for _, scope := range scopes {
+		if (filter == nil || filter(scope)) && scopeVisible(scope, chat, user, isAdmin) {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryLanguage return the primary language subtag of the IETF language tag, e.g. "en" of "en-US".
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+func scopeVisible(scope CommandScope, chat *tgbotapi.Chat, user *tgbotapi.User, isAdmin func() bool) bool {
+	if lc := scope.LanguageCode(); lc != "" && (user == nil || primaryLanguage(user.LanguageCode) != primaryLanguage(lc)) {
+		return false
+	}
+
+	if scope == noScope {
+		return true
+	}
+
+	isGroup := chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+	switch scope.Type() {
+	case ScopeTypeDefault:
+		return true
+	case ScopeTypeAllPrivateChats:
+		return chat != nil && chat.IsPrivate()
+	case ScopeTypeAllGroupChats:
+		return isGroup
+	case ScopeTypeAllChatAdministrators:
+		return isGroup && isAdmin()
+	case ScopeTypeChat:
+		return chat != nil && chat.ID == scope.ChatID()
+	case ScopeTypeChatAdministrators:
+		return chat != nil && chat.ID == scope.ChatID() && isAdmin()
+	case ScopeTypeChatMember:
+		return chat != nil && user != nil && chat.ID == scope.ChatID() && user.ID == scope.UserID()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestHelpRender(t *testing.T) {
+	noop := func(ctx *Context) error { return nil }
+	commands := map[string]*Command{
+		"ping": NewCommand("ping", "ping the bot.", noop),
+		"ban": NewCommand("ban", "ban a user", noop,
+			WithLongDescription("Ban the user <forever>."),
+			WithArgs(Arg{Name: "user", Type: ArgUser, Required: true, Description: "who to ban"}),
+		),
+		"secret": NewCommand("secret", "secret", noop, WithHide(true)),
+	}
+
+	h := &helpRenderer{parseMode: tgbotapi.ModeMarkdownV2}
+	except := "*Commands*\n/ban \\- ban a user\n/ping \\- ping the bot\\."
+	if text := h.renderList(commands); text != except {
+		t.Errorf("list except %q, got: %q", except, text)
+	}
+
+	h = &helpRenderer{parseMode: tgbotapi.ModeHTML}
+	except = "<b>/ban</b> - ban a user\n\nBan the user &lt;forever&gt;.\n\n" +
+		"Usage: <code>/ban &lt;user&gt;</code>\n\n<b>Arguments</b>\n• user (user, required) - who to ban"
+	if text := h.renderCommand(commands["ban"]); text != except {
+		t.Errorf("command except %q, got: %q", except, text)
+	}
+}
+
+func TestScopeVisible(t *testing.T) {
+	private := &tgbotapi.Chat{ID: 1, Type: "private"}
+	group := &tgbotapi.Chat{ID: -1, Type: "supergroup"}
+	user := &tgbotapi.User{ID: 2, LanguageCode: "en"}
+	notAdmin := func() bool { return false }
+
+	tests := []struct {
+		scope CommandScope
+		chat  *tgbotapi.Chat
+		user  *tgbotapi.User
+		ok    bool
+	}{
+		{scope: CommandScopeDefault(), chat: private, ok: true},
+		{scope: CommandScopeDefault("zh"), chat: private, ok: false},
+		{scope: CommandScopeAllPrivateChats("en"), chat: private, ok: true},
+		{scope: CommandScopeAllPrivateChats("en"), chat: private, user: &tgbotapi.User{ID: 2, LanguageCode: "en-US"}, ok: true},
+		{scope: CommandScopeAllPrivateChats("de"), chat: private, user: &tgbotapi.User{ID: 2, LanguageCode: "de-AT"}, ok: true},
+		{scope: CommandScopeAllPrivateChats(), chat: group, ok: false},
+		{scope: CommandScopeAllGroupChats(), chat: group, ok: true},
+		{scope: CommandScopeAllChatAdministrators(), chat: group, ok: false},
+		{scope: CommandScopeChat(-1), chat: group, ok: true},
+		{scope: CommandScopeChatMember(-1, 3), chat: group, ok: false},
+	}
+
+	for _, tt := range tests {
+		u := user
+		if tt.user != nil {
+			u = tt.user
+		}
+		if ok := scopeVisible(tt.scope, tt.chat, u, notAdmin); ok != tt.ok {
+			t.Errorf("scope %+v in chat %d except %v, got: %v", tt.scope, tt.chat.ID, tt.ok, ok)
+		}
+	}
+}
+
+func TestVisibleCommands(t *testing.T) {
+	noop := func(ctx *Context) error { return nil }
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.AddCommands(
+		NewCommand("start", "start", noop),
+		NewCommand("hello", "hello", noop, WithScopes(CommandScopeAllPrivateChats())),
+		NewCommand("hallo", "hallo", noop, WithScopes(CommandScopeAllPrivateChats("de"))),
+		NewCommand("debug", "debug", noop, WithHide(true)),
+	)
+
+	tests := []struct {
+		lang   string
+		except []string
+	}{
+		{lang: "de-AT", except: []string{"debug", "hallo"}},
+		{lang: "en-US", except: []string{"debug", "hello"}},
+	}
+
+	for _, tt := range tests {
+		ctx, recycle := bot.allocateContextWithUpdate(&tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: 1, Type: "private"},
+			From: &tgbotapi.User{ID: 1, LanguageCode: tt.lang},
+		}})
+
+		var names []string
+		for name := range bot.visibleCommands(ctx) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tt.except) {
+			t.Errorf("visible commands of %s except %v, got: %v", tt.lang, tt.except, names)
+		}
+		recycle()
+	}
+}
+
+func TestVisibleCommandsAdminCache(t *testing.T) {
+	var methods []string
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		fmt.Fprint(w, `{"ok": true, "result": [{"status": "administrator", "user": {"id": 2}}]}`)
+	})
+
+	noop := func(ctx *Context) error { return nil }
+	bot := NewBot(api)
+	bot.AddCommands(NewCommand("ban", "ban", noop, WithScopes(CommandScopeAllChatAdministrators())))
+
+	for i := 0; i < 2; i++ {
+		ctx, recycle := bot.allocateContextWithUpdate(&tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: -1, Type: "supergroup"},
+			From: &tgbotapi.User{ID: 2},
+		}})
+		if _, ok := bot.visibleCommands(ctx)["ban"]; !ok {
+			t.Errorf("admin command except visible to the administrator")
+		}
+		recycle()
+	}
+
+	if except := []string{"getChatAdministrators"}; !reflect.DeepEqual(methods, except) {
+		t.Errorf("requests except %v, got: %v", except, methods)
+	}
+}
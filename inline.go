@@ -0,0 +1,228 @@
+package tgbot
+
+import (
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxInlineResults is the maximum number of results in an answer of inline query.
+const MaxInlineResults = 50
+
+// OnInlineQuery register the handler for the inline queries whose query matches the pattern.
+//
+// The pattern is the same as OnCallback, e.g. "gif {keyword}" or "*" for any query,
+// note that "" only matches the empty query. The routes are matched in registration order,
+// the unmatched inline queries are handled by the OnAnyInlineQuery handler if set, otherwise
+// they fall through to the UpdatesHandler.
+func (bot *Bot) OnInlineQuery(pattern string, h Handler) {
+	if h == nil {
+		panic("tgbot: inline query handler must be non-nil")
+	}
+
+	re, err := compileCallbackPattern(pattern)
+	if err != nil {
+		panic("tgbot: invalid inline query pattern " + pattern + ": " + err.Error())
+	}
+
+	bot.inlineQueries = append(bot.inlineQueries, &callbackRoute{re: re, handler: h})
+}
+
+// inlineQueryRoute return the handler of the inline query, ok is false if no route matches.
+func (bot *Bot) inlineQueryRoute(ctx *Context) (Handler, bool) {
+	query := ctx.InlineQuery()
+	if query == nil {
+		return nil, false
+	}
+
+	for _, route := range bot.inlineQueries {
+		if params, ok := route.match(query.Query); ok {
+			ctx.params = params
+			return route.handler, true
+		}
+	}
+	return nil, false
+}
+
+// InlineQuery return the inline query if the update is an inline query.
+func (c *Context) InlineQuery() *tgbotapi.InlineQuery {
+	if c.update == nil {
+		return nil
+	}
+	return c.update.InlineQuery
+}
+
+// ChosenInlineResult return the chosen inline result if the update is a chosen inline result.
+func (c *Context) ChosenInlineResult() *tgbotapi.ChosenInlineResult {
+	if c.update == nil {
+		return nil
+	}
+	return c.update.ChosenInlineResult
+}
+
+// InlineOffset return the offset of the inline query which is set by AnswerInlinePage, 0 for the first page.
+func (c *Context) InlineOffset() int {
+	query := c.InlineQuery()
+	if query == nil {
+		return 0
+	}
+
+	offset, err := strconv.Atoi(query.Offset)
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// InlineAnswerOption is the option of answering the inline query.
+type InlineAnswerOption func(c *tgbotapi.InlineConfig)
+
+// WithCacheTime set how long in seconds the results are cached on telegram server.
+func WithCacheTime(seconds int) InlineAnswerOption {
+	return func(c *tgbotapi.InlineConfig) {
+		c.CacheTime = seconds
+	}
+}
+
+// WithPersonal set whether the results are cached only for the user who sent the query.
+func WithPersonal(personal bool) InlineAnswerOption {
+	return func(c *tgbotapi.InlineConfig) {
+		c.IsPersonal = personal
+	}
+}
+
+// WithNextOffset set the offset which is sent in the next query when the user scrolls the results.
+func WithNextOffset(offset string) InlineAnswerOption {
+	return func(c *tgbotapi.InlineConfig) {
+		c.NextOffset = offset
+	}
+}
+
+// WithSwitchPM show a button above the results which opens the private chat with the bot
+// and sends "/start parameter".
+func WithSwitchPM(text, parameter string) InlineAnswerOption {
+	return func(c *tgbotapi.InlineConfig) {
+		c.SwitchPMText = text
+		c.SwitchPMParameter = parameter
+	}
+}
+
+// AnswerInlineQuery answer the current inline query with the results.
+func (c *Context) AnswerInlineQuery(results []interface{}, opts ...InlineAnswerOption) error {
+	query := c.InlineQuery()
+	if query == nil {
+		return nil
+	}
+
+	config := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     c.bot.opts.inlineCacheTime,
+	}
+	if config.Results == nil {
+		config.Results = []interface{}{}
+	}
+	for _, o := range opts {
+		o(&config)
+	}
+	return c.SendReply(config)
+}
+
+// AnswerInlinePage answer the current inline query with a page of results starting at
+// InlineOffset, the next offset is set if there are more results, pageSize is at most MaxInlineResults.
+func (c *Context) AnswerInlinePage(results []interface{}, pageSize int, opts ...InlineAnswerOption) error {
+	if pageSize <= 0 || pageSize > MaxInlineResults {
+		pageSize = MaxInlineResults
+	}
+
+	start := c.InlineOffset()
+	if start > len(results) {
+		start = len(results)
+	}
+
+	end := start + pageSize
+	if end >= len(results) {
+		end = len(results)
+	} else {
+		opts = append([]InlineAnswerOption{WithNextOffset(strconv.Itoa(end))}, opts...)
+	}
+
+	return c.AnswerInlineQuery(results[start:end], opts...)
+}
+
+// InlineResults builds the results of inline query, the added results can be
+// modified by the returned pointer, e.g.
+//
+//	var results InlineResults
+//	results.Article("1", "Hello", "Hello world").Description = "say hello"
+//	ctx.AnswerInlineQuery(results.Results())
+type InlineResults struct {
+	results []interface{}
+}
+
+// Add add the result, it must be one of the tgbotapi.InlineQueryResult types.
+func (r *InlineResults) Add(result interface{}) {
+	r.results = append(r.results, result)
+}
+
+// Article add an article result whose message is text.
+func (r *InlineResults) Article(id, title, text string) *tgbotapi.InlineQueryResultArticle {
+	result := tgbotapi.NewInlineQueryResultArticle(id, title, text)
+	r.Add(&result)
+	return &result
+}
+
+// Photo add a photo result, thumb is the url of the thumbnail, it is the photo url if empty.
+func (r *InlineResults) Photo(id, url, thumb string) *tgbotapi.InlineQueryResultPhoto {
+	if thumb == "" {
+		thumb = url
+	}
+	result := tgbotapi.NewInlineQueryResultPhotoWithThumb(id, url, thumb)
+	r.Add(&result)
+	return &result
+}
+
+// GIF add a gif result.
+func (r *InlineResults) GIF(id, url string) *tgbotapi.InlineQueryResultGIF {
+	result := tgbotapi.NewInlineQueryResultGIF(id, url)
+	r.Add(&result)
+	return &result
+}
+
+// Video add a video result.
+func (r *InlineResults) Video(id, url string) *tgbotapi.InlineQueryResultVideo {
+	result := tgbotapi.NewInlineQueryResultVideo(id, url)
+	r.Add(&result)
+	return &result
+}
+
+// Audio add an audio result.
+func (r *InlineResults) Audio(id, url, title string) *tgbotapi.InlineQueryResultAudio {
+	result := tgbotapi.NewInlineQueryResultAudio(id, url, title)
+	r.Add(&result)
+	return &result
+}
+
+// Document add a document result, mimeType is "application/pdf" or "application/zip".
+func (r *InlineResults) Document(id, url, title, mimeType string) *tgbotapi.InlineQueryResultDocument {
+	result := tgbotapi.NewInlineQueryResultDocument(id, url, title, mimeType)
+	r.Add(&result)
+	return &result
+}
+
+// Location add a location result.
+func (r *InlineResults) Location(id, title string, latitude, longitude float64) *tgbotapi.InlineQueryResultLocation {
+	result := tgbotapi.NewInlineQueryResultLocation(id, title, latitude, longitude)
+	r.Add(&result)
+	return &result
+}
+
+// Len return the number of the results.
+func (r *InlineResults) Len() int {
+	return len(r.results)
+}
+
+// Results return the results for AnswerInlineQuery and AnswerInlinePage.
+func (r *InlineResults) Results() []interface{} {
+	return r.results
+}
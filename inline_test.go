@@ -0,0 +1,80 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestInlineQueryPage(t *testing.T) {
+	var (
+		nextOffset string
+		results    []map[string]interface{}
+	)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		nextOffset = r.FormValue("next_offset")
+		results = nil
+		_ = json.Unmarshal([]byte(r.FormValue("results")), &results)
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	})
+
+	bot := NewBot(api)
+	bot.OnInlineQuery("num {kind}", func(ctx *Context) error {
+		var r InlineResults
+		for i := 0; i < 5; i++ {
+			r.Article(strconv.Itoa(i), ctx.Param("kind"), "text")
+		}
+		return ctx.AnswerInlinePage(r.Results(), 2, WithCacheTime(10))
+	})
+
+	tests := []struct {
+		offset string
+		next   string
+		first  string
+		count  int
+	}{
+		{offset: "", next: "2", first: "0", count: 2},
+		{offset: "2", next: "4", first: "2", count: 2},
+		{offset: "4", next: "", first: "4", count: 1},
+	}
+
+	for _, tt := range tests {
+		bot.makeUpdateHandler(&tgbotapi.Update{
+			InlineQuery: &tgbotapi.InlineQuery{ID: "q", Query: "num odd", Offset: tt.offset},
+		})()
+
+		if nextOffset != tt.next || len(results) != tt.count || results[0]["id"] != tt.first {
+			t.Errorf("offset %q except next %q, %d results from %s, got: %q, %v",
+				tt.offset, tt.next, tt.count, tt.first, nextOffset, results)
+		}
+		if results[0]["title"] != "odd" {
+			t.Errorf("title except odd, got: %v", results[0]["title"])
+		}
+	}
+}
+
+func TestAnyInlineQuery(t *testing.T) {
+	var got []string
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.OnInlineQuery("gif {keyword}", func(ctx *Context) error {
+		got = append(got, "gif:"+ctx.Param("keyword"))
+		return nil
+	})
+	bot.OnAnyInlineQuery(func(ctx *Context) error {
+		got = append(got, "any:"+ctx.InlineQuery().Query)
+		return nil
+	})
+
+	for _, query := range []string{"gif cat", "hello"} {
+		bot.makeUpdateHandler(&tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: "q", Query: query}})()
+	}
+
+	except := []string{"gif:cat", "any:hello"}
+	if len(got) != len(except) || got[0] != except[0] || got[1] != except[1] {
+		t.Errorf("routes except %v, got: %v", except, got)
+	}
+}
@@ -0,0 +1,270 @@
+package tgbot
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxCallbackDataLength is the maximum length of the callback data in bytes.
+const MaxCallbackDataLength = 64
+
+// storedPayloadPrefix marks the payload which is stored in the CallbackStore.
+const storedPayloadPrefix = "~"
+
+var (
+	// ErrCallbackDataTooLong is returned when the callback data exceeds MaxCallbackDataLength.
+	ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+
+	// ErrCallbackPayloadExpired is returned when the stored payload is not found in the CallbackStore.
+	ErrCallbackPayloadExpired = errors.New("callback payload is expired")
+)
+
+// CallbackStore stores the oversized callback payloads, which are referenced by the short keys in the callback data.
+type CallbackStore interface {
+	// Save save the payload and return its key, the key must only contain A-Z, a-z, 0-9, _ and -.
+	Save(payload []byte) (key string, err error)
+
+	// Load load the payload of key, it returns ErrCallbackPayloadExpired if not found.
+	Load(key string) ([]byte, error)
+}
+
+type memoryCallbackEntry struct {
+	payload   []byte
+	expiresAt time.Time
+}
+
+type memoryCallbackStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	payloads  map[string]memoryCallbackEntry
+	lastSweep time.Time
+}
+
+// NewMemoryCallbackStore new a CallbackStore that keep the payloads in memory for ttl.
+func NewMemoryCallbackStore(ttl time.Duration) CallbackStore {
+	return &memoryCallbackStore{
+		ttl:       ttl,
+		payloads:  make(map[string]memoryCallbackEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memoryCallbackStore) Save(payload []byte) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := base64.RawURLEncoding.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.payloads[key] = memoryCallbackEntry{payload: payload, expiresAt: now.Add(s.ttl)}
+
+	// sweep the expired payloads at most once per ttl.
+	if now.Sub(s.lastSweep) > s.ttl {
+		s.lastSweep = now
+		for k, e := range s.payloads {
+			if now.After(e.expiresAt) {
+				delete(s.payloads, k)
+			}
+		}
+	}
+	return key, nil
+}
+
+// extendTTL extend the ttl of the payloads saved later to at least ttl.
+func (s *memoryCallbackStore) extendTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ttl > s.ttl {
+		s.ttl = ttl
+	}
+}
+
+func (s *memoryCallbackStore) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.payloads[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, ErrCallbackPayloadExpired
+	}
+	return e.payload, nil
+}
+
+// EncodeCallbackData encode v to the callback data "route:payload", the route can be matched
+// by OnCallback("route:*") and the payload is decoded by Context.CallbackPayload.
+// The oversized payload is saved in the store and referenced by its key if store is non-nil.
+func EncodeCallbackData(store CallbackStore, route string, v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal callback payload, error: %w", err)
+	}
+
+	data := route + ":" + base64.RawURLEncoding.EncodeToString(payload)
+	if len(data) <= MaxCallbackDataLength {
+		return data, nil
+	}
+
+	if store == nil {
+		return "", ErrCallbackDataTooLong
+	}
+	return saveCallbackData(store, route, payload)
+}
+
+// saveCallbackData save the payload in the store and return the callback data "route:~key".
+func saveCallbackData(store CallbackStore, route string, payload []byte) (string, error) {
+	key, err := store.Save(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to save callback payload, error: %w", err)
+	}
+
+	data := route + ":" + storedPayloadPrefix + key
+	if len(data) > MaxCallbackDataLength {
+		return "", ErrCallbackDataTooLong
+	}
+	return data, nil
+}
+
+// isStoredCallbackData report whether the payload of the callback data is stored in the CallbackStore.
+func isStoredCallbackData(data string) bool {
+	return strings.HasPrefix(data[strings.LastIndexByte(data, ':')+1:], storedPayloadPrefix)
+}
+
+// decodeCallbackData decode the payload of the callback data encoded by EncodeCallbackData into v.
+func decodeCallbackData(store CallbackStore, data string, v interface{}) error {
+	encoded := data[strings.LastIndexByte(data, ':')+1:]
+
+	var (
+		payload []byte
+		err     error
+	)
+	if key := strings.TrimPrefix(encoded, storedPayloadPrefix); key != encoded {
+		if store == nil {
+			return ErrCallbackPayloadExpired
+		}
+		payload, err = store.Load(key)
+	} else {
+		payload, err = base64.RawURLEncoding.DecodeString(encoded)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("failed to unmarshal callback payload, error: %w", err)
+	}
+	return nil
+}
+
+// CallbackPayload decode the payload of the current callback query encoded by Keyboard.Data into v.
+func (c *Context) CallbackPayload(v interface{}) error {
+	return decodeCallbackData(c.bot.opts.callbackStore, c.CallbackData(), v)
+}
+
+// Keyboard builds the inline keyboard, e.g.
+//
+//	markup, err := ctx.Keyboard().Columns(2).
+//		Callback("Yes", "vote:yes").
+//		Callback("No", "vote:no").
+//		Row().URL("Docs", "https://example.com").
+//		Markup()
+//
+// The first error is returned by Markup.
+type Keyboard struct {
+	store   CallbackStore
+	columns int
+	rows    [][]tgbotapi.InlineKeyboardButton
+	err     error
+}
+
+// NewKeyboard new a Keyboard, the oversized payloads of Data are rejected.
+func NewKeyboard() *Keyboard {
+	return &Keyboard{}
+}
+
+// Keyboard new a Keyboard which saves the oversized payloads of Data in the callback store of the bot.
+func (c *Context) Keyboard() *Keyboard {
+	return &Keyboard{store: c.bot.opts.callbackStore}
+}
+
+// Columns set the maximum number of buttons per row, a new row is started when the row is full.
+func (k *Keyboard) Columns(n int) *Keyboard {
+	k.columns = n
+	return k
+}
+
+// Row start a new row.
+func (k *Keyboard) Row() *Keyboard {
+	if n := len(k.rows); n == 0 || len(k.rows[n-1]) > 0 {
+		k.rows = append(k.rows, nil)
+	}
+	return k
+}
+
+func (k *Keyboard) add(button tgbotapi.InlineKeyboardButton) *Keyboard {
+	n := len(k.rows)
+	if n == 0 || (k.columns > 0 && len(k.rows[n-1]) >= k.columns) {
+		k.rows = append(k.rows, nil)
+		n++
+	}
+	k.rows[n-1] = append(k.rows[n-1], button)
+	return k
+}
+
+// Callback add a button which sends the callback query with data.
+func (k *Keyboard) Callback(text, data string) *Keyboard {
+	if len(data) > MaxCallbackDataLength && k.err == nil {
+		k.err = fmt.Errorf("button %q: %w", text, ErrCallbackDataTooLong)
+	}
+	return k.add(tgbotapi.NewInlineKeyboardButtonData(text, data))
+}
+
+// Data add a button which sends the callback query with v encoded by EncodeCallbackData.
+func (k *Keyboard) Data(text, route string, v interface{}) *Keyboard {
+	data, err := EncodeCallbackData(k.store, route, v)
+	if err != nil && k.err == nil {
+		k.err = fmt.Errorf("button %q: %w", text, err)
+	}
+	return k.add(tgbotapi.NewInlineKeyboardButtonData(text, data))
+}
+
+// URL add a button which opens the url.
+func (k *Keyboard) URL(text, url string) *Keyboard {
+	return k.add(tgbotapi.NewInlineKeyboardButtonURL(text, url))
+}
+
+// SwitchInline add a button which lets the user choose a chat and inserts the inline query in it.
+func (k *Keyboard) SwitchInline(text, query string) *Keyboard {
+	return k.add(tgbotapi.NewInlineKeyboardButtonSwitch(text, query))
+}
+
+// SwitchInlineCurrentChat add a button which inserts the inline query in the current chat.
+func (k *Keyboard) SwitchInlineCurrentChat(text, query string) *Keyboard {
+	return k.add(tgbotapi.InlineKeyboardButton{Text: text, SwitchInlineQueryCurrentChat: &query})
+}
+
+// Markup return the inline keyboard markup.
+func (k *Keyboard) Markup() (tgbotapi.InlineKeyboardMarkup, error) {
+	if k.err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, k.err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(k.rows))
+	for _, row := range k.rows {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
@@ -0,0 +1,62 @@
+package tgbot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestKeyboard(t *testing.T) {
+	markup, err := NewKeyboard().Columns(2).
+		Callback("1", "n:1").Callback("2", "n:2").Callback("3", "n:3").
+		Row().URL("Docs", "https://example.com").SwitchInline("Share", "q").
+		Markup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, row := range markup.InlineKeyboard {
+		sizes = append(sizes, len(row))
+	}
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 1 || sizes[2] != 2 {
+		t.Errorf("row sizes except [2 1 2], got: %v", sizes)
+	}
+
+	_, err = NewKeyboard().Callback("long", strings.Repeat("x", 65)).Markup()
+	if !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("long data except ErrCallbackDataTooLong, got: %v", err)
+	}
+}
+
+func TestCallbackPayload(t *testing.T) {
+	type payload struct {
+		ID   int    `json:"id"`
+		Note string `json:"note"`
+	}
+
+	bot := NewBot(&tgbotapi.BotAPI{}, WithCallbackStore(NewMemoryCallbackStore(time.Minute)))
+	ctx := &Context{bot: bot}
+
+	for _, v := range []payload{{ID: 1}, {ID: 2, Note: strings.Repeat("n", 100)}} {
+		markup, err := ctx.Keyboard().Data("btn", "item", v).Markup()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := *markup.InlineKeyboard[0][0].CallbackData
+		if len(data) > MaxCallbackDataLength || !strings.HasPrefix(data, "item:") {
+			t.Fatalf("callback data except item:<payload> within 64 bytes, got: %s", data)
+		}
+
+		ctx.update = &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: data}}
+
+		var got payload
+		if err := ctx.CallbackPayload(&got); err != nil || got != v {
+			t.Errorf("payload except %+v, got: %+v, %v", v, got, err)
+		}
+	}
+}
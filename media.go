@@ -0,0 +1,230 @@
+package tgbot
+
+import (
+	"encoding/json"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type mediaOptions struct {
+	chatID              int64
+	caption             string
+	parseMode           string
+	captionEntities     []tgbotapi.MessageEntity
+	replyTo             int
+	replyMarkup         interface{}
+	disableNotification bool
+}
+
+// MediaOption is the option of the media replies.
+type MediaOption func(o *mediaOptions)
+
+// WithCaption set the caption of the media, it is ignored by the sticker and location.
+func WithCaption(caption string) MediaOption {
+	return func(o *mediaOptions) {
+		o.caption = caption
+	}
+}
+
+// WithCaptionParseMode set the parse mode of the caption.
+func WithCaptionParseMode(mode string) MediaOption {
+	return func(o *mediaOptions) {
+		o.parseMode = mode
+	}
+}
+
+// WithCaptionEntities set the entities of the caption instead of the parse mode.
+func WithCaptionEntities(entities []tgbotapi.MessageEntity) MediaOption {
+	return func(o *mediaOptions) {
+		o.captionEntities = entities
+	}
+}
+
+// WithMediaReplyMarkup set the reply markup, such as tgbotapi.InlineKeyboardMarkup.
+func WithMediaReplyMarkup(markup interface{}) MediaOption {
+	return func(o *mediaOptions) {
+		o.replyMarkup = markup
+	}
+}
+
+// WithMediaReplyTo set the message which the media replies to.
+func WithMediaReplyTo(messageID int) MediaOption {
+	return func(o *mediaOptions) {
+		o.replyTo = messageID
+	}
+}
+
+// WithMediaDisableNotification send the media silently.
+func WithMediaDisableNotification(disable bool) MediaOption {
+	return func(o *mediaOptions) {
+		o.disableNotification = disable
+	}
+}
+
+// WithMediaChatId set the chat id which the media is sent to.
+func WithMediaChatId(chatId int64) MediaOption {
+	return func(o *mediaOptions) {
+		o.chatID = chatId
+	}
+}
+
+func (c *Context) mediaOptions(opts []MediaOption) *mediaOptions {
+	o := &mediaOptions{}
+	if chat := c.FromChat(); chat != nil {
+		o.chatID = chat.ID
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *mediaOptions) baseChat() tgbotapi.BaseChat {
+	return tgbotapi.BaseChat{
+		ChatID:              o.chatID,
+		ReplyToMessageID:    o.replyTo,
+		ReplyMarkup:         o.replyMarkup,
+		DisableNotification: o.disableNotification,
+	}
+}
+
+func (o *mediaOptions) baseFile(file tgbotapi.RequestFileData) tgbotapi.BaseFile {
+	return tgbotapi.BaseFile{BaseChat: o.baseChat(), File: file}
+}
+
+// ReplyPhoto reply a photo to the current chat, file is one of tgbotapi.FilePath,
+// tgbotapi.FileReader, tgbotapi.FileURL, tgbotapi.FileID and tgbotapi.FileBytes, e.g.
+//
+//	ctx.ReplyPhoto(tgbotapi.FilePath("cat.jpg"), WithCaption("cat"))
+//
+// The request with tgbotapi.FileReader is not retried by the retry policy since the reader can not be read again.
+func (c *Context) ReplyPhoto(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.PhotoConfig{
+		BaseFile:        o.baseFile(file),
+		Caption:         o.caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	})
+}
+
+// ReplyDocument reply a document to the current chat, file is the same as ReplyPhoto.
+func (c *Context) ReplyDocument(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.DocumentConfig{
+		BaseFile:        o.baseFile(file),
+		Caption:         o.caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	})
+}
+
+// ReplyVideo reply a video to the current chat, file is the same as ReplyPhoto.
+func (c *Context) ReplyVideo(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.VideoConfig{
+		BaseFile:        o.baseFile(file),
+		Caption:         o.caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	})
+}
+
+// ReplyAudio reply an audio to the current chat, file is the same as ReplyPhoto.
+func (c *Context) ReplyAudio(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.AudioConfig{
+		BaseFile:        o.baseFile(file),
+		Caption:         o.caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	})
+}
+
+// ReplyVoice reply a voice to the current chat, file is the same as ReplyPhoto.
+func (c *Context) ReplyVoice(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.VoiceConfig{
+		BaseFile:        o.baseFile(file),
+		Caption:         o.caption,
+		ParseMode:       o.parseMode,
+		CaptionEntities: o.captionEntities,
+	})
+}
+
+// ReplySticker reply a sticker to the current chat, file is the same as ReplyPhoto.
+func (c *Context) ReplySticker(file tgbotapi.RequestFileData, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.StickerConfig{BaseFile: o.baseFile(file)})
+}
+
+// ReplyLocation reply a location to the current chat.
+func (c *Context) ReplyLocation(latitude, longitude float64, opts ...MediaOption) (*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+	return c.sendMessage(tgbotapi.LocationConfig{
+		BaseChat:  o.baseChat(),
+		Latitude:  latitude,
+		Longitude: longitude,
+	})
+}
+
+// ReplyMediaGroup reply an album to the current chat, media is the slice of tgbotapi.InputMediaPhoto,
+// tgbotapi.InputMediaVideo, tgbotapi.InputMediaAudio or tgbotapi.InputMediaDocument,
+// the caption options are applied to the first media.
+func (c *Context) ReplyMediaGroup(media []interface{}, opts ...MediaOption) ([]*tgbotapi.Message, error) {
+	o := c.mediaOptions(opts)
+
+	if len(media) > 0 && (o.caption != "" || len(o.captionEntities) > 0) {
+		media = append([]interface{}{withMediaCaption(media[0], o)}, media[1:]...)
+	}
+
+	resp, err := c.Request(tgbotapi.MediaGroupConfig{
+		ChatID:              o.chatID,
+		Media:               media,
+		ReplyToMessageID:    o.replyTo,
+		DisableNotification: o.disableNotification,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// sendMessage send the chattable and return the sent message like Reply.
+func (c *Context) sendMessage(chat tgbotapi.Chattable) (*tgbotapi.Message, error) {
+	msg, err := c.Send(chat)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func withMediaCaption(media interface{}, o *mediaOptions) interface{} {
+	setCaption := func(base *tgbotapi.BaseInputMedia) {
+		base.Caption = o.caption
+		base.ParseMode = o.parseMode
+		base.CaptionEntities = o.captionEntities
+	}
+
+	switch m := media.(type) {
+	case tgbotapi.InputMediaPhoto:
+		setCaption(&m.BaseInputMedia)
+		return m
+	case tgbotapi.InputMediaVideo:
+		setCaption(&m.BaseInputMedia)
+		return m
+	case tgbotapi.InputMediaAudio:
+		setCaption(&m.BaseInputMedia)
+		return m
+	case tgbotapi.InputMediaDocument:
+		setCaption(&m.BaseInputMedia)
+		return m
+	default:
+		return media
+	}
+}
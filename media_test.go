@@ -0,0 +1,56 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestReplyMedia(t *testing.T) {
+	var params map[string]string
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		params = map[string]string{"method": r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]}
+		for k := range r.Form {
+			params[k] = r.Form.Get(k)
+		}
+
+		if params["method"] == "sendMediaGroup" {
+			fmt.Fprint(w, `{"ok": true, "result": [{"message_id": 7}, {"message_id": 8}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 7}}`)
+	})
+
+	ctx := &Context{BotAPI: api, update: newCommandUpdate("/photo")}
+
+	msg, err := ctx.ReplyPhoto(tgbotapi.FileURL("https://example.com/cat.jpg"),
+		WithCaption("<b>cat</b>"), WithCaptionParseMode(tgbotapi.ModeHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.MessageID != 7 {
+		t.Errorf("reply photo except message 7, got: %v", msg.MessageID)
+	}
+	if params["method"] != "sendPhoto" || params["photo"] != "https://example.com/cat.jpg" ||
+		params["caption"] != "<b>cat</b>" || params["parse_mode"] != "HTML" || params["chat_id"] != "1" {
+		t.Errorf("reply photo params unexpected, got: %v", params)
+	}
+
+	messages, err := ctx.ReplyMediaGroup([]interface{}{
+		tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("a")),
+		tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("b")),
+	}, WithCaption("album"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[1].MessageID != 8 {
+		t.Errorf("reply media group except messages 7 and 8, got: %v", messages)
+	}
+	if !strings.Contains(params["media"], `"caption":"album"`) {
+		t.Errorf("media group except caption on first media, got: %s", params["media"])
+	}
+}
@@ -0,0 +1,19 @@
+package tgbot
+
+// Middleware wraps the Handler to run the cross-cutting logic,
+// such as auth, logging, metrics before or after the next Handler.
+type Middleware func(next Handler) Handler
+
+// Use add the global middlewares, they are applied to the commands,
+// the undefined command handler and the updates handler in order.
+func (bot *Bot) Use(middlewares ...Middleware) {
+	bot.middlewares = append(bot.middlewares, middlewares...)
+}
+
+// chain wraps the handler with the middlewares, the first middleware is the outermost.
+func chain(h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
@@ -0,0 +1,52 @@
+package tgbot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newCommandUpdate(text string) *tgbotapi.Update {
+	length := len(text)
+	if i := strings.IndexByte(text, ' '); i >= 0 {
+		length = i
+	}
+
+	return &tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			Text: text,
+			Chat: &tgbotapi.Chat{ID: 1},
+			Entities: []tgbotapi.MessageEntity{
+				{Type: "bot_command", Offset: 0, Length: length},
+			},
+		},
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx *Context) error {
+				calls = append(calls, name)
+				return next(ctx)
+			}
+		}
+	}
+
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.Use(record("global1"), record("global2"))
+	bot.AddCommands(NewCommand("ping", "ping", func(ctx *Context) error {
+		calls = append(calls, "handler")
+		return nil
+	}, WithMiddlewares(record("command"))))
+
+	bot.makeUpdateHandler(newCommandUpdate("/ping"))()
+
+	except := []string{"global1", "global2", "command", "handler"}
+	if !reflect.DeepEqual(calls, except) {
+		t.Errorf("calls except %v, got: %v", except, calls)
+	}
+}
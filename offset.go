@@ -0,0 +1,173 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OffsetStore persists the getUpdates offset, the offset is saved only after
+// all the updates before it have been handled.
+type OffsetStore interface {
+	// Load return the saved offset, zero if there is no saved offset.
+	Load() (int, error)
+
+	// Save save the offset.
+	Save(offset int) error
+}
+
+type memoryOffsetStore struct {
+	mu     sync.Mutex
+	offset int
+}
+
+// NewMemoryOffsetStore new an OffsetStore that keep the offset in memory.
+func NewMemoryOffsetStore() OffsetStore {
+	return &memoryOffsetStore{}
+}
+
+func (s *memoryOffsetStore) Load() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset, nil
+}
+
+func (s *memoryOffsetStore) Save(offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = offset
+	return nil
+}
+
+type fileOffsetStore struct {
+	path string
+}
+
+// NewFileOffsetStore new an OffsetStore that keep the offset in the file of path.
+func NewFileOffsetStore(path string) OffsetStore {
+	return &fileOffsetStore{path: path}
+}
+
+func (s *fileOffsetStore) Load() (int, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func (s *fileOffsetStore) Save(offset int) error {
+	return writeFileAtomic(s.path, []byte(strconv.Itoa(offset)))
+}
+
+// writeFileAtomic write to a temp file and rename it, so the file is never partially written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// offsetTracker tracks the dispatched updates and commits the offset
+// once all the updates before it have been handled.
+type offsetTracker struct {
+	store OffsetStore
+
+	mu sync.Mutex
+
+	// offset is the committed offset.
+	offset int
+
+	// pending is the dispatched but uncommitted update ids in ascending order.
+	pending []int
+	handled map[int]struct{}
+
+	// changed is closed when the committed offset changes.
+	changed chan struct{}
+}
+
+func newOffsetTracker(store OffsetStore, offset int) *offsetTracker {
+	return &offsetTracker{
+		store:   store,
+		offset:  offset,
+		handled: make(map[int]struct{}),
+		changed: make(chan struct{}),
+	}
+}
+
+func (t *offsetTracker) committed() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.offset
+}
+
+func (t *offsetTracker) dispatch(updateID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, updateID)
+}
+
+// commit mark the update as handled and save the offset if it is advanced.
+func (t *offsetTracker) commit(updateID int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handled[updateID] = struct{}{}
+
+	offset := t.offset
+	for len(t.pending) > 0 {
+		id := t.pending[0]
+		if _, ok := t.handled[id]; !ok {
+			break
+		}
+		delete(t.handled, id)
+		t.pending = t.pending[1:]
+		offset = id + 1
+	}
+
+	if offset == t.offset {
+		return nil
+	}
+
+	t.offset = offset
+	close(t.changed)
+	t.changed = make(chan struct{})
+
+	return t.store.Save(offset)
+}
+
+// wait block until the committed offset is not equal to offset or ctx is done.
+func (t *offsetTracker) wait(ctx context.Context, offset int) {
+	t.mu.Lock()
+	if t.offset != offset {
+		t.mu.Unlock()
+		return
+	}
+	changed := t.changed
+	t.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-changed:
+	}
+}
@@ -0,0 +1,61 @@
+package tgbot
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOffsetTrackerCommit(t *testing.T) {
+	store := NewMemoryOffsetStore()
+	if err := store.Save(10); err != nil {
+		t.Fatal(err)
+	}
+	tracker := newOffsetTracker(store, 10)
+
+	for _, id := range []int{10, 11, 12} {
+		tracker.dispatch(id)
+	}
+
+	commits := []struct {
+		updateID int
+		offset   int
+	}{
+		{updateID: 11, offset: 10},
+		{updateID: 12, offset: 10},
+		{updateID: 10, offset: 13},
+	}
+
+	for _, c := range commits {
+		if err := tracker.commit(c.updateID); err != nil {
+			t.Fatal(err)
+		}
+
+		if offset, _ := store.Load(); offset != c.offset {
+			t.Errorf("commit %d, offset except %d, got: %d", c.updateID, c.offset, offset)
+		}
+	}
+}
+
+func TestFileOffsetStore(t *testing.T) {
+	store := NewFileOffsetStore(filepath.Join(t.TempDir(), "offset"))
+
+	offset, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 0 {
+		t.Errorf("offset except %d, got: %d", 0, offset)
+	}
+
+	if err := store.Save(42); err != nil {
+		t.Fatal(err)
+	}
+
+	offset, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 42 {
+		t.Errorf("offset except %d, got: %d", 42, offset)
+	}
+}
@@ -17,6 +17,9 @@ type Handler func(ctx *Context) error
 // ErrHandler error handler.
 type ErrHandler func(err error)
 
+// ArgsErrorHandler handle the invalid command arguments.
+type ArgsErrorHandler func(ctx *Context, err *ArgsError) error
+
 // PanicHandler is panic handler.
 type PanicHandler func(*Context, interface{})
 
@@ -29,19 +32,38 @@ type options struct {
 	// disableAutoSetupCommands whether automatically set up commands.
 	disableAutoSetupCommands bool
 
+	// caseInsensitiveCommands whether match the command names case-insensitively.
+	caseInsensitiveCommands bool
+
 	disableHandleAllUpdateOnStop bool
 
-	undefinedCommandHandler Handler
-	errHandler              ErrHandler
-	updatesHandler          UpdatesHandler
-	panicHandler            PanicHandler
+	undefinedCommandHandler  Handler
+	forbiddenHandler         Handler
+	cooldownHandler          LimitHandler
+	undefinedCallbackHandler Handler
+	argsErrorHandler         ArgsErrorHandler
+	errHandler               ErrHandler
+	updatesHandler           UpdatesHandler
+	panicHandler             PanicHandler
 
 	// pollUpdatesErrorHandler is the handler that is called when an error occurs in the polling updates.
 	pollUpdatesErrorHandler ErrHandler
 
+	// pollUpdatesBackoff is the backoff after getting updates failed.
+	pollUpdatesBackoff Backoff
+
+	// circuitBreakerThreshold is the consecutive failures to open the circuit breaker.
+	circuitBreakerThreshold int
+
+	// circuitBreakerCooldown is how long getting updates is paused after the circuit breaker opened.
+	circuitBreakerCooldown time.Duration
+
 	workersNum  int
 	workersPool Pool
 
+	// shardKey is used to process the updates of the same key sequentially.
+	shardKey ShardKeyFunc
+
 	// bufSize is updateC chan buffer size.
 	bufSize int
 
@@ -49,6 +71,49 @@ type options struct {
 	limit          int
 	offset         int
 	allowedUpdates []string
+
+	// retryPolicy is used to retry the failed requests if it is non-nil.
+	retryPolicy *RetryPolicy
+
+	// rateLimiter limits the requests sent by Context.
+	rateLimiter RateLimiter
+
+	sessionStore SessionStore
+	sessionKey   SessionKeyFunc
+
+	// conversationStore stores the conversation states.
+	conversationStore ConversationStore
+
+	// counterStore counts the command invocations for the cooldowns.
+	counterStore CounterStore
+
+	// inlineCacheTime is the default cache time in seconds of the inline query answers.
+	inlineCacheTime int
+
+	// templates is the message templates of Context.ReplyTemplate.
+	templates *Templates
+
+	// callbackStore stores the oversized callback payloads of Keyboard.Data.
+	callbackStore CallbackStore
+
+	// deepLinkSecret signs the deep link payloads if it is non-nil.
+	deepLinkSecret []byte
+
+	// owners is the user ids of the bot owners.
+	owners map[int64]struct{}
+
+	// adminCacheTTL is how long the chat administrators are cached.
+	adminCacheTTL time.Duration
+
+	// offsetStore persists the offset of getUpdates.
+	offsetStore OffsetStore
+
+	// webhookURL is the url which telegram sends updates to, setWebhook is skipped if it is empty.
+	webhookURL                 string
+	webhookSecretToken         string
+	webhookMaxConnections      int
+	webhookDropPendingUpdates  bool
+	disableDeleteWebhookOnStop bool
 }
 
 func newOptions(opts ...Option) *options {
@@ -59,8 +124,18 @@ func newOptions(opts ...Option) *options {
 
 		workersNum: runtime.GOMAXPROCS(0),
 
+		sessionKey: SessionKeyByChatAndUser,
+
 		updateTimeout: 50, // 50s is maximum timeout.
 		limit:         100,
+
+		pollUpdatesBackoff:      ExponentialBackoff(time.Second, time.Minute, 0.2),
+		circuitBreakerThreshold: 5,
+		circuitBreakerCooldown:  time.Minute,
+
+		adminCacheTTL: 5 * time.Minute,
+
+		counterStore: NewMemoryCounterStore(),
 	}
 
 	o.panicHandler = func(ctx *Context, v interface{}) {
@@ -69,7 +144,6 @@ func newOptions(opts ...Option) *options {
 
 	o.pollUpdatesErrorHandler = func(err error) {
 		o.errHandler(fmt.Errorf("failed to get updates, error: %w", err))
-		time.Sleep(3 * time.Second)
 	}
 
 	for _, opt := range opts {
@@ -109,6 +183,15 @@ func WithWorkersPool(p Pool) Option {
 	}
 }
 
+// WithShardKey set the shard key of updates, the updates with the same key are processed
+// sequentially in order, and the updates with different keys are processed concurrently,
+// e.g. WithShardKey(ShardByChat) keeps the updates of a chat in order.
+func WithShardKey(f ShardKeyFunc) Option {
+	return func(o *options) {
+		o.shardKey = f
+	}
+}
+
 // WithUndefinedCmdHandler set how to handle undefined commands.
 func WithUndefinedCmdHandler(h Handler) Option {
 	return func(o *options) {
@@ -116,6 +199,20 @@ func WithUndefinedCmdHandler(h Handler) Option {
 	}
 }
 
+// WithUndefinedCallbackHandler set how to handle the callback queries which match no route.
+func WithUndefinedCallbackHandler(h Handler) Option {
+	return func(o *options) {
+		o.undefinedCallbackHandler = h
+	}
+}
+
+// WithArgsErrorHandler set how to handle the invalid command arguments, default replies the usage.
+func WithArgsErrorHandler(h ArgsErrorHandler) Option {
+	return func(o *options) {
+		o.argsErrorHandler = h
+	}
+}
+
 // WithErrorHandler set error handler.
 func WithErrorHandler(h ErrHandler) Option {
 	return func(o *options) {
@@ -130,6 +227,81 @@ func WithDisableAutoSetupCommands(v bool) Option {
 	}
 }
 
+// WithInlineCacheTime set the default cache time in seconds of the inline query answers,
+// it is overridden by WithCacheTime.
+func WithInlineCacheTime(seconds int) Option {
+	return func(o *options) {
+		o.inlineCacheTime = seconds
+	}
+}
+
+// WithTemplates set the message templates of Context.ReplyTemplate.
+func WithTemplates(t *Templates) Option {
+	return func(o *options) {
+		o.templates = t
+	}
+}
+
+// WithCallbackStore set the store of the oversized callback payloads and the pagination states,
+// the oversized payloads are rejected if it is nil, AddPagination sets a memory store if it is nil.
+func WithCallbackStore(store CallbackStore) Option {
+	return func(o *options) {
+		o.callbackStore = store
+	}
+}
+
+// WithDeepLinkSecret set the secret to sign the deep link payloads, the signature takes about 11 characters of the payload.
+func WithDeepLinkSecret(secret []byte) Option {
+	return func(o *options) {
+		o.deepLinkSecret = secret
+	}
+}
+
+// WithOwners set the user ids of the bot owners for RoleOwner.
+func WithOwners(ids ...int64) Option {
+	return func(o *options) {
+		o.owners = make(map[int64]struct{}, len(ids))
+		for _, id := range ids {
+			o.owners[id] = struct{}{}
+		}
+	}
+}
+
+// WithAdminCacheTTL set how long the chat administrators are cached.
+func WithAdminCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.adminCacheTTL = ttl
+	}
+}
+
+// WithForbiddenHandler set the handler that is called when the sender is not allowed to run the command.
+func WithForbiddenHandler(h Handler) Option {
+	return func(o *options) {
+		o.forbiddenHandler = h
+	}
+}
+
+// WithCounterStore set the counter store of the command cooldowns.
+func WithCounterStore(store CounterStore) Option {
+	return func(o *options) {
+		o.counterStore = store
+	}
+}
+
+// WithCooldownHandler set the handler that is called when the command is in cooldown.
+func WithCooldownHandler(h LimitHandler) Option {
+	return func(o *options) {
+		o.cooldownHandler = h
+	}
+}
+
+// WithCaseInsensitiveCommands match the command names and aliases case-insensitively.
+func WithCaseInsensitiveCommands(v bool) Option {
+	return func(o *options) {
+		o.caseInsensitiveCommands = v
+	}
+}
+
 // WithDisableHandleAllUpdateOnStop disable handle all updates on stop.
 func WithDisableHandleAllUpdateOnStop(v bool) Option {
 	return func(o *options) {
@@ -158,6 +330,28 @@ func WithPanicHandler(h PanicHandler) Option {
 	}
 }
 
+// WithPollUpdatesBackoff set the backoff after getting updates failed.
+func WithPollUpdatesBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.pollUpdatesBackoff = b
+	}
+}
+
+// WithCircuitBreakerCooldown set how long getting updates is paused after the circuit breaker opened,
+// then a single probe is sent to decide whether to close it.
+func WithCircuitBreakerCooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.circuitBreakerCooldown = d
+	}
+}
+
+// WithCircuitBreakerThreshold set the consecutive failures of getting updates to open the circuit breaker.
+func WithCircuitBreakerThreshold(n int) Option {
+	return func(o *options) {
+		o.circuitBreakerThreshold = n
+	}
+}
+
 // WithGetUpdatesTimeout set the get updates updateTimeout,
 // timeout unit is seconds, max is 50 second.
 func WithGetUpdatesTimeout(timeout int) Option {
@@ -179,3 +373,84 @@ func WithGetUpdatesAllowedUpdates(v ...string) Option {
 		o.allowedUpdates = v
 	}
 }
+
+// WithOffsetStore set the offset store, the offset is committed to the store after
+// the update is handled, so the unhandled updates are redelivered after restart.
+func WithOffsetStore(s OffsetStore) Option {
+	return func(o *options) {
+		o.offsetStore = s
+	}
+}
+
+// WithRetryPolicy set the retry policy of the requests sent by Context and the get updates,
+// e.g. WithRetryPolicy(DefaultRetryPolicy).
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = p
+	}
+}
+
+// WithRateLimiter set the rate limiter of the requests which send messages by Context,
+// e.g. WithRateLimiter(NewRateLimiter(DefaultRateLimits)).
+func WithRateLimiter(l RateLimiter) Option {
+	return func(o *options) {
+		o.rateLimiter = l
+	}
+}
+
+// WithSessionStore set the session store, it must be specified to use Context.Session.
+func WithSessionStore(s SessionStore) Option {
+	return func(o *options) {
+		o.sessionStore = s
+	}
+}
+
+// WithSessionKey set how to get the session key of the update, default is SessionKeyByChatAndUser.
+func WithSessionKey(f SessionKeyFunc) Option {
+	return func(o *options) {
+		o.sessionKey = f
+	}
+}
+
+// WithConversationStore set the conversation store, default is in memory.
+func WithConversationStore(s ConversationStore) Option {
+	return func(o *options) {
+		o.conversationStore = s
+	}
+}
+
+// WithWebhook set the webhook url, the webhook is set up when RunWebhook is called.
+func WithWebhook(url string) Option {
+	return func(o *options) {
+		o.webhookURL = url
+	}
+}
+
+// WithWebhookSecretToken set the webhook secret token, the requests without the
+// matching X-Telegram-Bot-Api-Secret-Token header will be rejected.
+func WithWebhookSecretToken(token string) Option {
+	return func(o *options) {
+		o.webhookSecretToken = token
+	}
+}
+
+// WithWebhookMaxConnections set the maximum allowed number of simultaneous connections to the webhook.
+func WithWebhookMaxConnections(n int) Option {
+	return func(o *options) {
+		o.webhookMaxConnections = n
+	}
+}
+
+// WithWebhookDropPendingUpdates drop all pending updates when set up the webhook.
+func WithWebhookDropPendingUpdates(v bool) Option {
+	return func(o *options) {
+		o.webhookDropPendingUpdates = v
+	}
+}
+
+// WithDisableDeleteWebhookOnStop disable delete the webhook set by WithWebhook on stop.
+func WithDisableDeleteWebhookOnStop(v bool) Option {
+	return func(o *options) {
+		o.disableDeleteWebhookOnStop = v
+	}
+}
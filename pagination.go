@@ -0,0 +1,218 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Page is a page of the paginated list.
+type Page struct {
+	Text string
+
+	// HasNext reports whether there is a next page.
+	HasNext bool
+
+	// Total is the number of the pages, it is shown on the navigation if it is positive.
+	Total int
+}
+
+// PageFunc fetch the page of the list, arg is passed by Context.ReplyPage, page starts from 0,
+// the navigation to a page beyond Total fails with ErrPageOutOfRange.
+type PageFunc func(ctx *Context, arg string, page int) (*Page, error)
+
+// ErrPageOutOfRange is returned when the page of the navigation is not in the list.
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// Pagination is a list message whose pages are navigated by the inline buttons,
+// the message is edited in place when the buttons are clicked.
+//
+// The state of the navigation is kept in the CallbackStore set by WithCallbackStore and
+// only its key is sent in the callback data, so the arg is not limited by the size of the
+// callback data and can not be forged by the client. AddPagination sets a memory store
+// which keeps the state for the longest TTL of the paginations if the bot has no store.
+type Pagination struct {
+	name        string
+	fetch       PageFunc
+	parseMode   string
+	ttl         time.Duration
+	expiredText string
+}
+
+// PaginationOption is the option of Pagination.
+type PaginationOption func(p *Pagination)
+
+// WithPageParseMode set the parse mode of the page text.
+func WithPageParseMode(mode string) PaginationOption {
+	return func(p *Pagination) {
+		p.parseMode = mode
+	}
+}
+
+// WithPageTTL set how long the navigation works after the list is sent, default is 24 hours.
+func WithPageTTL(ttl time.Duration) PaginationOption {
+	return func(p *Pagination) {
+		p.ttl = ttl
+	}
+}
+
+// WithPageExpiredText set the text shown when the expired navigation is clicked.
+func WithPageExpiredText(text string) PaginationOption {
+	return func(p *Pagination) {
+		p.expiredText = text
+	}
+}
+
+// NewPagination new a Pagination, name identifies the pagination in the callback data.
+func NewPagination(name string, fetch PageFunc, opts ...PaginationOption) *Pagination {
+	p := &Pagination{
+		name:        name,
+		fetch:       fetch,
+		ttl:         24 * time.Hour,
+		expiredText: "This list has expired, please request it again.",
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// pageState is the state of the pagination saved in the CallbackStore.
+type pageState struct {
+	Arg       string `json:"a,omitempty"`
+	Page      int    `json:"p,omitempty"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// paginationNoop is the payload of the page indicator button.
+const paginationNoop = "-"
+
+func (p *Pagination) route() string {
+	return "page:" + p.name
+}
+
+// AddPagination register the callback route of the pagination.
+func (bot *Bot) AddPagination(p *Pagination) {
+	if bot.opts.callbackStore == nil {
+		bot.pageStore = NewMemoryCallbackStore(p.ttl).(*memoryCallbackStore)
+		bot.opts.callbackStore = bot.pageStore
+	}
+	if bot.pageStore != nil {
+		bot.pageStore.extendTTL(p.ttl)
+	}
+	bot.OnCallback(p.route()+":*", p.handle)
+}
+
+// ReplyPage reply the first page of the pagination to the current chat.
+func (c *Context) ReplyPage(p *Pagination, arg string) error {
+	state := pageState{Arg: arg, ExpiresAt: time.Now().Add(p.ttl).Unix()}
+	text, opts, err := p.render(c, state)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.reply(text, opts...)
+	return err
+}
+
+func (p *Pagination) handle(ctx *Context) error {
+	data := ctx.CallbackData()
+	if strings.HasSuffix(data, ":"+paginationNoop) {
+		return nil
+	}
+
+	// the state encoded in the callback data is not trusted, it is only saved in the store.
+	if !isStoredCallbackData(data) {
+		return p.expire(ctx)
+	}
+
+	var state pageState
+	if err := decodeCallbackData(ctx.bot.opts.callbackStore, data, &state); err != nil {
+		if errors.Is(err, ErrCallbackPayloadExpired) {
+			return p.expire(ctx)
+		}
+		return err
+	}
+
+	if time.Now().Unix() > state.ExpiresAt || state.Page < 0 {
+		return p.expire(ctx)
+	}
+
+	text, opts, err := p.render(ctx, state)
+	if err != nil {
+		return err
+	}
+	return ctx.EditText(nil, text, opts...)
+}
+
+// expire tell the user the list is expired and remove the navigation.
+func (p *Pagination) expire(ctx *Context) error {
+	if err := ctx.AnswerCallback(p.expiredText); err != nil {
+		return err
+	}
+	return ctx.EditMarkup(nil, tgbotapi.NewInlineKeyboardMarkup())
+}
+
+// render fetch the page of the state and return the text and the options with the navigation.
+func (p *Pagination) render(ctx *Context, state pageState) (string, []MessageOption, error) {
+	page, err := p.fetch(ctx, state.Arg, state.Page)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to fetch page %d of %s, error: %w", state.Page, p.name, err)
+	}
+	if page.Total > 0 && state.Page >= page.Total {
+		return "", nil, fmt.Errorf("failed to fetch page %d of %s, error: %w", state.Page, p.name, ErrPageOutOfRange)
+	}
+
+	opts := []MessageOption{WithParseMode(p.parseMode), WithDisableWebPagePreview(true)}
+	if state.Page == 0 && !page.HasNext {
+		return page.Text, opts, nil
+	}
+
+	kb := ctx.Keyboard()
+	if state.Page > 0 {
+		prev := state
+		prev.Page--
+		data, err := p.save(ctx, prev)
+		if err != nil {
+			return "", nil, err
+		}
+		kb.Callback("« Prev", data)
+	}
+	if page.Total > 0 {
+		kb.Callback(strconv.Itoa(state.Page+1)+"/"+strconv.Itoa(page.Total), p.route()+":"+paginationNoop)
+	}
+	if page.HasNext {
+		next := state
+		next.Page++
+		data, err := p.save(ctx, next)
+		if err != nil {
+			return "", nil, err
+		}
+		kb.Callback("Next »", data)
+	}
+
+	markup, err := kb.Markup()
+	if err != nil {
+		return "", nil, err
+	}
+	return page.Text, append(opts, WithInlineKeyboardMarkup(markup)), nil
+}
+
+// save save the state in the callback store and return the callback data of the navigation.
+func (p *Pagination) save(ctx *Context, state pageState) (string, error) {
+	store := ctx.bot.opts.callbackStore
+	if store == nil {
+		return "", fmt.Errorf("tgbot: pagination %s requires a callback store, register it by AddPagination", p.name)
+	}
+
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal page state, error: %w", err)
+	}
+	return saveCallbackData(store, p.route(), payload)
+}
@@ -0,0 +1,106 @@
+package tgbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestPagination(t *testing.T) {
+	var (
+		calls  []string
+		markup tgbotapi.InlineKeyboardMarkup
+	)
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		calls = append(calls, method+":"+r.Form.Get("text"))
+		if v := r.Form.Get("reply_markup"); v != "" {
+			markup = tgbotapi.InlineKeyboardMarkup{}
+			_ = json.Unmarshal([]byte(v), &markup)
+		}
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 1, "chat": {"id": 1}}}`)
+	})
+
+	items := []string{"a", "b", "c"}
+	p := NewPagination("items", func(ctx *Context, arg string, page int) (*Page, error) {
+		if page >= len(items) {
+			return &Page{Total: len(items)}, nil
+		}
+		return &Page{Text: strings.TrimSpace(arg) + " " + items[page], HasNext: page < len(items)-1, Total: len(items)}, nil
+	})
+
+	bot := NewBot(api)
+	bot.AddPagination(p)
+
+	ctx, recycle := bot.allocateContextWithUpdate(newCommandUpdate("/list"))
+	defer recycle()
+	arg := "item " + strings.Repeat(" ", 64)
+	if err := ctx.ReplyPage(p, arg); err != nil {
+		t.Fatal(err)
+	}
+
+	click := func(button int) {
+		data := *markup.InlineKeyboard[0][button].CallbackData
+		bot.makeUpdateHandler(&tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "q",
+			Data:    data,
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 1}},
+		}})()
+	}
+
+	click(1) // next
+	if n := len(markup.InlineKeyboard[0]); n != 3 {
+		t.Fatalf("middle page except 3 buttons, got: %v", n)
+	}
+	click(0) // prev
+
+	except := []string{
+		"sendMessage:item a",
+		"editMessageText:item b", "answerCallbackQuery:",
+		"editMessageText:item a", "answerCallbackQuery:",
+	}
+	if strings.Join(calls, ",") != strings.Join(except, ",") {
+		t.Errorf("calls except %v, got: %v", except, calls)
+	}
+
+	expired, _ := json.Marshal(pageState{Page: 1, ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	expiredData, _ := saveCallbackData(bot.opts.callbackStore, p.route(), expired)
+	forgedData, _ := EncodeCallbackData(nil, p.route(), pageState{Page: -1, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	for _, data := range []string{expiredData, forgedData} {
+		calls = nil
+		bot.makeUpdateHandler(&tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "q",
+			Data:    data,
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 1}},
+		}})()
+
+		except = []string{"answerCallbackQuery:" + p.expiredText, "editMessageReplyMarkup:"}
+		if strings.Join(calls, ",") != strings.Join(except, ",") {
+			t.Errorf("%s calls except %v, got: %v", data, except, calls)
+		}
+	}
+
+	if _, _, err := p.render(ctx, pageState{Page: 3}); !errors.Is(err, ErrPageOutOfRange) {
+		t.Errorf("render page 3 except ErrPageOutOfRange, got: %v", err)
+	}
+}
+
+func TestPaginationStoreTTL(t *testing.T) {
+	fetch := func(ctx *Context, arg string, page int) (*Page, error) { return &Page{}, nil }
+
+	bot := NewBot(&tgbotapi.BotAPI{})
+	bot.AddPagination(NewPagination("short", fetch, WithPageTTL(time.Minute)))
+	bot.AddPagination(NewPagination("long", fetch, WithPageTTL(time.Hour)))
+
+	if ttl := bot.pageStore.ttl; ttl != time.Hour {
+		t.Errorf("store ttl except %v, got: %v", time.Hour, ttl)
+	}
+}
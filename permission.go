@@ -0,0 +1,241 @@
+package tgbot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Role report whether the sender of the update has the role.
+type Role func(ctx *Context) (bool, error)
+
+// WithRoles require the sender to have any of the roles to run the command,
+// WithRoles can be used multiple times and the sender must satisfy all of them, e.g.
+//
+//	WithRoles(RoleOwner, RoleChatAdmin), WithRoles(RoleGroupChat)
+//
+// allows the owners and the chat administrators in group chats.
+func WithRoles(roles ...Role) CommandOption {
+	return func(cmd *Command) {
+		cmd.roles = append(cmd.roles, roles)
+	}
+}
+
+// RoleOwner is the bot owners set by WithOwners.
+func RoleOwner(ctx *Context) (bool, error) {
+	user := ctx.SentFrom()
+	if user == nil {
+		return false, nil
+	}
+
+	_, ok := ctx.bot.opts.owners[user.ID]
+	return ok, nil
+}
+
+// RoleChatAdmin is the administrators of the chat, including the creator.
+func RoleChatAdmin(ctx *Context) (bool, error) {
+	member, ok, err := ctx.bot.chatAdmin(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	return member.IsAdministrator() || member.IsCreator(), nil
+}
+
+// RoleChatCreator is the creator of the chat.
+func RoleChatCreator(ctx *Context) (bool, error) {
+	member, ok, err := ctx.bot.chatAdmin(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	return member.IsCreator(), nil
+}
+
+// RoleUsers is the users of the ids.
+func RoleUsers(ids ...int64) Role {
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+
+	return func(ctx *Context) (bool, error) {
+		user := ctx.SentFrom()
+		if user == nil {
+			return false, nil
+		}
+
+		_, ok := set[user.ID]
+		return ok, nil
+	}
+}
+
+// RolePrivateChat is anyone in private chats.
+func RolePrivateChat(ctx *Context) (bool, error) {
+	chat := ctx.FromChat()
+	return chat != nil && chat.IsPrivate(), nil
+}
+
+// RoleGroupChat is anyone in group and supergroup chats.
+func RoleGroupChat(ctx *Context) (bool, error) {
+	chat := ctx.FromChat()
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup()), nil
+}
+
+// authorize report whether the sender is allowed to run the command.
+func (bot *Bot) authorize(ctx *Context, cmd *Command) (bool, error) {
+	for _, roles := range cmd.roles {
+		var allowed bool
+		for _, role := range roles {
+			ok, err := role(ctx)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (bot *Bot) forbiddenHandler(ctx *Context) error {
+	if bot.opts.forbiddenHandler != nil {
+		return bot.opts.forbiddenHandler(ctx)
+	}
+
+	return ctx.ReplyText("You are not allowed to use this command.")
+}
+
+// chatAdmin return the administrator of the chat who sent the update,
+// ok is false if the sender is not an administrator.
+func (bot *Bot) chatAdmin(ctx *Context) (member tgbotapi.ChatMember, ok bool, err error) {
+	chat, user := ctx.FromChat(), ctx.SentFrom()
+	if chat == nil || user == nil || chat.IsPrivate() {
+		return member, false, nil
+	}
+
+	admins, err := bot.admins.get(ctx, chat.ID)
+	if err != nil {
+		return member, false, err
+	}
+
+	member, ok = admins[user.ID]
+	return member, ok, nil
+}
+
+type adminEntry struct {
+	members   map[int64]tgbotapi.ChatMember
+	expiresAt time.Time
+}
+
+// adminCall is an in-flight request of the chat administrators, the concurrent
+// misses of the chat wait for it instead of sending their own requests.
+type adminCall struct {
+	done    chan struct{}
+	members map[int64]tgbotapi.ChatMember
+	err     error
+}
+
+// adminCache caches the administrators of the chats.
+type adminCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	chats     map[int64]*adminEntry
+	calls     map[int64]*adminCall
+	lastSweep time.Time
+}
+
+func newAdminCache(ttl time.Duration) *adminCache {
+	return &adminCache{
+		ttl:   ttl,
+		chats: make(map[int64]*adminEntry),
+		calls: make(map[int64]*adminCall),
+	}
+}
+
+func (c *adminCache) get(ctx *Context, chatID int64) (map[int64]tgbotapi.ChatMember, error) {
+	c.mu.Lock()
+	now := time.Now()
+	c.sweep(now)
+
+	if e, ok := c.chats[chatID]; ok && now.Before(e.expiresAt) {
+		c.mu.Unlock()
+		return e.members, nil
+	}
+
+	if call, ok := c.calls[chatID]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.members, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &adminCall{done: make(chan struct{})}
+	c.calls[chatID] = call
+	c.mu.Unlock()
+
+	call.members, call.err = c.fetch(ctx, chatID)
+
+	c.mu.Lock()
+	// the call is removed by invalidate if the administrators changed while it is in flight,
+	// the result is returned but not cached.
+	if c.calls[chatID] == call {
+		delete(c.calls, chatID)
+		if call.err == nil {
+			c.chats[chatID] = &adminEntry{members: call.members, expiresAt: time.Now().Add(c.ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.members, call.err
+}
+
+func (c *adminCache) fetch(ctx *Context, chatID int64) (map[int64]tgbotapi.ChatMember, error) {
+	admins, err := ctx.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat administrators, error: %w", err)
+	}
+
+	members := make(map[int64]tgbotapi.ChatMember, len(admins))
+	for _, member := range admins {
+		if member.User != nil {
+			members[member.User.ID] = member
+		}
+	}
+	return members, nil
+}
+
+// sweep remove the expired chats at most once per minute, c.mu must be held.
+func (c *adminCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) <= time.Minute {
+		return
+	}
+
+	c.lastSweep = now
+	for id, e := range c.chats {
+		if !now.Before(e.expiresAt) {
+			delete(c.chats, id)
+		}
+	}
+}
+
+// invalidate remove the cached administrators of the chat.
+func (c *adminCache) invalidate(chatID int64) {
+	c.mu.Lock()
+	delete(c.chats, chatID)
+	delete(c.calls, chatID)
+	c.mu.Unlock()
+}
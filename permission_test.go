@@ -0,0 +1,108 @@
+package tgbot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCommandRoles(t *testing.T) {
+	var adminRequests, forbidden int32
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getChatAdministrators"):
+			atomic.AddInt32(&adminRequests, 1)
+			fmt.Fprint(w, `{"ok": true, "result": [{"status": "administrator", "user": {"id": 2}}]}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			atomic.AddInt32(&forbidden, 1)
+			fmt.Fprint(w, `{"ok": true, "result": {"message_id": 1}}`)
+		}
+	})
+
+	var calls int
+	bot := NewBot(api, WithOwners(1))
+	bot.AddCommands(NewCommand("ban", "ban", func(ctx *Context) error {
+		calls++
+		return nil
+	}, WithRoles(RoleOwner, RoleChatAdmin), WithRoles(RoleGroupChat)))
+
+	tests := []struct {
+		userID int64
+		chat   string
+		calls  int
+	}{
+		{userID: 1, chat: "group", calls: 1},
+		{userID: 2, chat: "supergroup", calls: 2},
+		{userID: 3, chat: "group", calls: 2},
+		{userID: 1, chat: "private", calls: 2},
+	}
+
+	for _, tt := range tests {
+		update := newCommandUpdate("/ban")
+		update.Message.Chat.Type = tt.chat
+		update.Message.From = &tgbotapi.User{ID: tt.userID}
+
+		bot.makeUpdateHandler(update)()
+		if calls != tt.calls {
+			t.Errorf("user %d in %s chat calls except %v, got: %v", tt.userID, tt.chat, tt.calls, calls)
+		}
+	}
+
+	if n := atomic.LoadInt32(&adminRequests); n != 1 {
+		t.Errorf("getChatAdministrators except 1 request, got: %v", n)
+	}
+	if n := atomic.LoadInt32(&forbidden); n != 2 {
+		t.Errorf("forbidden replies except 2, got: %v", n)
+	}
+}
+
+func TestAdminCacheConcurrentMiss(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, `{"ok": true, "result": [{"status": "creator", "user": {"id": 2}}]}`)
+	})
+
+	bot := NewBot(api)
+	ctx, recycle := bot.allocateContextWithUpdate(&tgbotapi.Update{})
+	defer recycle()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if admins, err := bot.admins.get(ctx, -1); err != nil || len(admins) != 1 {
+				t.Errorf("admins except 1, got: %v, %v", admins, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("getChatAdministrators except 1 request, got: %v", n)
+	}
+
+	bot.admins.mu.Lock()
+	bot.admins.chats[-2] = &adminEntry{expiresAt: time.Now().Add(-time.Second)}
+	bot.admins.lastSweep = time.Time{}
+	bot.admins.mu.Unlock()
+
+	if _, err := bot.admins.get(ctx, -1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bot.admins.chats[-2]; ok {
+		t.Errorf("expired chat except evicted")
+	}
+}
@@ -0,0 +1,258 @@
+package tgbot
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the outgoing requests which send messages.
+type RateLimiter interface {
+	// Wait block until the request of the method to the chat is allowed or ctx is done,
+	// chatID is empty if the request has no chat.
+	Wait(ctx context.Context, method string, chatID string) error
+}
+
+// RateLimits is the limits of the RateLimiter.
+type RateLimits struct {
+	// Global is the maximum messages per second to all the chats.
+	Global float64
+
+	// Chat is the maximum messages per second to a chat.
+	Chat float64
+
+	// GroupPerMinute is the maximum messages per minute to a group or channel.
+	GroupPerMinute int
+}
+
+// DefaultRateLimits is the limits recommended by telegram.
+var DefaultRateLimits = RateLimits{
+	Global:         30,
+	Chat:           1,
+	GroupPerMinute: 20,
+}
+
+// bucket is a token bucket, the tokens can be negative to reserve
+// the future tokens, so the waiters are served in FIFO order.
+type bucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+func (b *bucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// reserve take a token and return how long to wait before using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel return the token which is reserved but not used.
+func (b *bucket) cancel() {
+	b.tokens++
+}
+
+// full report whether the bucket is full, the full bucket is the same as a new one.
+func (b *bucket) full(now time.Time) bool {
+	b.advance(now)
+	return b.tokens >= b.burst
+}
+
+type rateLimiter struct {
+	limits RateLimits
+
+	mu        sync.Mutex
+	global    *bucket
+	chats     map[string]*bucket
+	groups    map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter new a RateLimiter with the limits, the zero limit is unlimited.
+func NewRateLimiter(limits RateLimits) RateLimiter {
+	now := time.Now()
+	l := &rateLimiter{
+		limits:    limits,
+		chats:     make(map[string]*bucket),
+		groups:    make(map[string]*bucket),
+		lastSweep: now,
+	}
+	if limits.Global > 0 {
+		l.global = newBucket(limits.Global, int(limits.Global), now)
+	}
+	return l
+}
+
+func (l *rateLimiter) Wait(ctx context.Context, method string, chatID string) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.sweep(now)
+
+	// reserve a token of every bucket and wait for the latest one.
+	buckets := l.buckets(chatID, now)
+	var d time.Duration
+	for _, b := range buckets {
+		if w := b.reserve(now); w > d {
+			d = w
+		}
+	}
+	l.mu.Unlock()
+
+	if d <= 0 || sleepContext(ctx, d) {
+		return nil
+	}
+
+	// give back all the reserved tokens since the request is not sent.
+	l.mu.Lock()
+	for _, b := range buckets {
+		b.cancel()
+	}
+	l.mu.Unlock()
+	return ctx.Err()
+}
+
+// buckets return the buckets which limit the request to the chat.
+func (l *rateLimiter) buckets(chatID string, now time.Time) []*bucket {
+	var buckets []*bucket
+	if chatID != "" {
+		// group and channel ids are negative.
+		if l.limits.GroupPerMinute > 0 && strings.HasPrefix(chatID, "-") {
+			b, ok := l.groups[chatID]
+			if !ok {
+				b = newBucket(float64(l.limits.GroupPerMinute)/60, l.limits.GroupPerMinute, now)
+				l.groups[chatID] = b
+			}
+			buckets = append(buckets, b)
+		}
+
+		if l.limits.Chat > 0 {
+			b, ok := l.chats[chatID]
+			if !ok {
+				b = newBucket(l.limits.Chat, 1, now)
+				l.chats[chatID] = b
+			}
+			buckets = append(buckets, b)
+		}
+	}
+
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	return buckets
+}
+
+// sweep remove the idle chat buckets at most once per minute.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for _, m := range []map[string]*bucket{l.chats, l.groups} {
+		for id, b := range m {
+			if b.full(now) {
+				delete(m, id)
+			}
+		}
+	}
+}
+
+// isRateLimitedMethod report whether the method sends or changes messages.
+func isRateLimitedMethod(method string) bool {
+	for _, prefix := range []string{"send", "forward", "copy", "edit"} {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestChatID read the chat_id of the request, the read body is restored.
+func requestChatID(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return "", nil
+		}
+		return values.Get("chat_id"), nil
+
+	case "multipart/form-data":
+		// the fields are written before the files, so only the fields are read
+		// and the read data is replayed before the rest of the body.
+		var (
+			read bytes.Buffer
+			body = req.Body
+		)
+		defer func() {
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(&read, body), body}
+		}()
+
+		mr := multipart.NewReader(io.TeeReader(body, &read), params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return "", nil
+			}
+
+			if part.FileName() != "" {
+				return "", nil
+			}
+
+			if part.FormName() == "chat_id" {
+				value, err := io.ReadAll(part)
+				if err != nil {
+					return "", nil
+				}
+				return string(value), nil
+			}
+		}
+
+	default:
+		return "", nil
+	}
+}
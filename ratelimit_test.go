@@ -0,0 +1,150 @@
+package tgbot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestChatID(t *testing.T) {
+	form := url.Values{"chat_id": {"-100"}, "text": {"hi"}}.Encode()
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/sendMessage", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if chatID, err := requestChatID(req); err != nil || chatID != "-100" {
+		t.Errorf("form chat id except %q, got: %q, %v", "-100", chatID, err)
+	}
+	if body, _ := io.ReadAll(req.Body); string(body) != form {
+		t.Errorf("form body except %q, got: %q", form, body)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	_ = w.WriteField("caption", "hi")
+	_ = w.WriteField("chat_id", "42")
+	fw, _ := w.CreateFormFile("photo", "a.png")
+	_, _ = fw.Write(bytes.Repeat([]byte{'x'}, 10000))
+	_ = w.Close()
+	data := buf.Bytes()
+
+	req, _ = http.NewRequest(http.MethodPost, "http://localhost/sendPhoto", bytes.NewReader(data))
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	if chatID, err := requestChatID(req); err != nil || chatID != "42" {
+		t.Errorf("multipart chat id except %q, got: %q, %v", "42", chatID, err)
+	}
+	if body, _ := io.ReadAll(req.Body); !bytes.Equal(body, data) {
+		t.Error("multipart body is not restored")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(RateLimits{Chat: 20})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), "sendMessage", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("3 messages to a chat at 20/s except wait at least %v, got: %v", 100*time.Millisecond, d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the other chat is not limited by the chat limit, so it does not wait even if ctx is done.
+	if err := l.Wait(ctx, "sendMessage", "2"); err != nil {
+		t.Errorf("message to other chat except no wait, got: %v", err)
+	}
+
+	_ = l.Wait(context.Background(), "sendMessage", "3")
+	if err := l.Wait(ctx, "sendMessage", "3"); err != context.Canceled {
+		t.Errorf("canceled wait except %v, got: %v", context.Canceled, err)
+	}
+}
+
+func TestRateLimiterCancelRefund(t *testing.T) {
+	l := NewRateLimiter(RateLimits{Chat: 1, GroupPerMinute: 20}).(*rateLimiter)
+
+	if err := l.Wait(context.Background(), "sendMessage", "-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, "sendMessage", "-1"); err != context.Canceled {
+		t.Fatalf("canceled wait except %v, got: %v", context.Canceled, err)
+	}
+
+	// the group token reserved by the canceled wait is given back.
+	if tokens := l.groups["-1"].tokens; tokens < 18.5 {
+		t.Errorf("group tokens except about 19, got: %v", tokens)
+	}
+}
+
+func TestRateLimitedContextStop(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 1}}`)
+	})
+
+	bot := NewBot(api, WithRateLimiter(NewRateLimiter(RateLimits{Chat: 0.001})))
+	ctx, recycle := bot.allocateContextWithUpdate(newCommandUpdate("/ping"))
+	defer recycle()
+
+	if err := ctx.ReplyText("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second message waits for the chat limit until the bot is stopped.
+	bot.cancel()
+	if err := ctx.ReplyText("second"); !errors.Is(err, context.Canceled) {
+		t.Errorf("reply after stop except %v, got: %v", context.Canceled, err)
+	}
+}
+
+func TestClientCloseBodyOnWaitError(t *testing.T) {
+	l := NewRateLimiter(RateLimits{Chat: 0.001})
+	if err := l.Wait(context.Background(), "sendPhoto", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &client{cli: http.DefaultClient, ctx: ctx, limiter: l}
+
+	// the body is written through a pipe like the uploads of tgbotapi.
+	r, w := io.Pipe()
+	mw := multipart.NewWriter(w)
+	written := make(chan error, 1)
+	go func() {
+		_ = mw.WriteField("chat_id", "1")
+		fw, _ := mw.CreateFormFile("photo", "a.png")
+		_, err := fw.Write(bytes.Repeat([]byte{'x'}, 1<<20))
+		written <- err
+	}()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/sendPhoto", r)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if _, err := c.Do(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled upload except %v, got: %v", context.Canceled, err)
+	}
+
+	select {
+	case err := <-written:
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("upload writer except %v, got: %v", io.ErrClosedPipe, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("upload writer is blocked after the canceled wait")
+	}
+}
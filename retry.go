@@ -0,0 +1,222 @@
+package tgbot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Backoff return how long to wait before the next attempt, attempt starts from 1.
+type Backoff interface {
+	Backoff(attempt int) time.Duration
+}
+
+type exponentialBackoff struct {
+	min, max time.Duration
+	jitter   float64
+}
+
+// ExponentialBackoff return a Backoff that doubles the wait duration from min to max,
+// jitter is the random factor in [0, 1] applied to the wait duration.
+func ExponentialBackoff(min, max time.Duration, jitter float64) Backoff {
+	return &exponentialBackoff{min: min, max: max, jitter: jitter}
+}
+
+func (b *exponentialBackoff) Backoff(attempt int) time.Duration {
+	d := b.min
+	for i := 1; i < attempt && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		d = b.max
+	}
+
+	if b.jitter > 0 {
+		d += time.Duration(float64(d) * b.jitter * (rand.Float64()*2 - 1))
+	}
+	return d
+}
+
+// RetryPolicy is the policy to retry the failed requests, the request is retried if
+// telegram answers too many requests or server error, or the network error occurs.
+// The server errors include the non-JSON 5xx responses of the gateways, such as 502 and 504.
+//
+// The network errors are retried only if the request is not sent, such as the dial errors,
+// or the request is idempotent, such as getChat, since the message may have been sent
+// when the response is lost. The requests uploading files from io.Reader are never retried
+// since the reader can not be read again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// Backoff is the backoff for server errors and network errors, the backoff of
+	// DefaultRetryPolicy is used if it is nil, the too many requests errors wait exactly the retry_after.
+	Backoff Backoff
+
+	// OnRetry is called before waiting for the next attempt if it is non-nil.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// defaultRetryBackoff is the backoff of DefaultRetryPolicy.
+var defaultRetryBackoff = ExponentialBackoff(500*time.Millisecond, 30*time.Second, 0.2)
+
+// DefaultRetryPolicy is the default retry policy.
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     defaultRetryBackoff,
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p.Backoff == nil {
+		return defaultRetryBackoff.Backoff(attempt)
+	}
+	return p.Backoff.Backoff(attempt)
+}
+
+// retryWait return how long to wait before retry the err, ok is false if err is not retryable,
+// idempotent report whether the request can be sent again after it reached telegram.
+func (p *RetryPolicy) retryWait(attempt int, err error, idempotent bool) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.RetryAfter > 0:
+			return time.Duration(apiErr.RetryAfter) * time.Second, true
+
+		case apiErr.Code >= 500:
+			return p.backoff(attempt), true
+		}
+		return 0, false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	if notSent(err) {
+		return p.backoff(attempt), true
+	}
+
+	var urlErr *url.Error
+	if idempotent && (errors.As(err, &urlErr) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		return p.backoff(attempt), true
+	}
+	return 0, false
+}
+
+// notSent report whether the request failed before it was sent.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// readOnlyConfigs is the configs of the read-only methods whose names do not start with "get".
+var readOnlyConfigs = map[string]bool{
+	"ChatInfoConfig":           true,
+	"ChatAdministratorsConfig": true,
+	"ChatMemberCountConfig":    true,
+	"UserProfilePhotosConfig":  true,
+	"FileConfig":               true,
+	"UpdateConfig":             true,
+}
+
+// idempotent report whether sending the chattable again has no more effects,
+// they are the getting, setting and deleting configs.
+func idempotent(chat tgbotapi.Chattable) bool {
+	t := reflect.TypeOf(chat)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	name := t.Name()
+	for _, prefix := range []string{"Get", "Set", "Delete"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return readOnlyConfigs[name]
+}
+
+var fileReaderType = reflect.TypeOf(tgbotapi.FileReader{})
+
+// replayable report whether the chattable can be sent again, the files read from io.Reader can not.
+func replayable(chat tgbotapi.Chattable) bool {
+	return !hasFileReader(reflect.ValueOf(chat), 0)
+}
+
+func hasFileReader(v reflect.Value, depth int) bool {
+	if depth > 8 {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return !v.IsNil() && hasFileReader(v.Elem(), depth+1)
+
+	case reflect.Struct:
+		if v.Type() == fileReaderType {
+			return true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if hasFileReader(v.Field(i), depth+1) {
+				return true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasFileReader(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// do call f until it succeeds, the error is not retryable, the attempts are exhausted or ctx is done.
+func (p *RetryPolicy) do(ctx context.Context, idempotent bool, f func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		wait, ok := p.retryWait(attempt, err, idempotent)
+		if !ok {
+			return err
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+
+		if !sleepContext(ctx, wait) {
+			return err
+		}
+	}
+}
+
+// sleepContext sleep d, report false if ctx is done before d elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
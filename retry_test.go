@@ -0,0 +1,131 @@
+package tgbot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRetryPolicyWait(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 3, Backoff: ExponentialBackoff(time.Second, 4*time.Second, 0)}
+
+	tests := []struct {
+		err        error
+		idempotent bool
+		wait       time.Duration
+		ok         bool
+	}{
+		{err: &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}}, wait: 7 * time.Second, ok: true},
+		{err: &tgbotapi.Error{Code: 502}, wait: 2 * time.Second, ok: true},
+		{err: &tgbotapi.Error{Code: 400}, ok: false},
+		{err: fmt.Errorf("other"), ok: false},
+		{err: &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("refused")}}, wait: 2 * time.Second, ok: true},
+		{err: &url.Error{Op: "Post", Err: errors.New("timeout")}, ok: false},
+		{err: &url.Error{Op: "Post", Err: errors.New("timeout")}, idempotent: true, wait: 2 * time.Second, ok: true},
+	}
+
+	for _, tt := range tests {
+		wait, ok := p.retryWait(2, tt.err, tt.idempotent)
+		if wait != tt.wait || ok != tt.ok {
+			t.Errorf("retry %v except (%v, %v), got: (%v, %v)", tt.err, tt.wait, tt.ok, wait, ok)
+		}
+	}
+}
+
+func TestContextRequestRetry(t *testing.T) {
+	var requests int32
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			fmt.Fprint(w, `{"ok": false, "error_code": 500, "description": "Internal Server Error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	})
+
+	var retries []int
+	bot := NewBot(api, WithRetryPolicy(&RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ExponentialBackoff(time.Millisecond, time.Millisecond, 0),
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			retries = append(retries, attempt)
+		},
+	}))
+
+	ctx, recycle := bot.allocateContextWithUpdate(&tgbotapi.Update{})
+	defer recycle()
+
+	if _, err := ctx.Request(tgbotapi.NewDeleteMyCommands()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests except %d, got: %d", 2, n)
+	}
+	if len(retries) != 1 {
+		t.Errorf("retries except %d, got: %d", 1, len(retries))
+	}
+}
+
+func TestContextRequestRetryGatewayError(t *testing.T) {
+	var requests int32
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "<html><body>502 Bad Gateway</body></html>")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	})
+
+	// the nil backoff falls back to the default backoff.
+	bot := NewBot(api, WithRetryPolicy(&RetryPolicy{MaxAttempts: 2}))
+	if wait, ok := bot.opts.retryPolicy.retryWait(1, &tgbotapi.Error{Code: 502}, false); !ok || wait <= 0 {
+		t.Errorf("nil backoff except default wait, got: (%v, %v)", wait, ok)
+	}
+
+	ctx, recycle := bot.allocateContextWithUpdate(&tgbotapi.Update{})
+	defer recycle()
+
+	if _, err := ctx.Request(tgbotapi.NewDeleteMyCommands()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests except %d, got: %d", 2, n)
+	}
+}
+
+func TestRetryRequestKind(t *testing.T) {
+	tests := []struct {
+		chat       tgbotapi.Chattable
+		idempotent bool
+		replayable bool
+	}{
+		{chat: tgbotapi.NewMessage(1, "hi"), replayable: true},
+		{chat: tgbotapi.NewDeleteMyCommands(), idempotent: true, replayable: true},
+		{chat: tgbotapi.ChatInfoConfig{}, idempotent: true, replayable: true},
+		{chat: tgbotapi.NewPhoto(1, tgbotapi.FilePath("a.jpg")), replayable: true},
+		{chat: tgbotapi.NewPhoto(1, tgbotapi.FileReader{Name: "a.jpg", Reader: strings.NewReader("x")})},
+		{chat: tgbotapi.NewMediaGroup(1, []interface{}{
+			tgbotapi.NewInputMediaPhoto(tgbotapi.FileReader{Name: "a.jpg", Reader: strings.NewReader("x")}),
+		})},
+	}
+
+	for _, tt := range tests {
+		if v := idempotent(tt.chat); v != tt.idempotent {
+			t.Errorf("%T idempotent except %v, got: %v", tt.chat, tt.idempotent, v)
+		}
+		if v := replayable(tt.chat); v != tt.replayable {
+			t.Errorf("%T replayable except %v, got: %v", tt.chat, tt.replayable, v)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package tgbot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdateTypeChatJoinRequest is the update type of the chat join requests, it is missing in tgbotapi.
+const UpdateTypeChatJoinRequest = "chat_join_request"
+
+// updateTypeAny is the key of the catch-all handler set by OnUpdate.
+const updateTypeAny = "*"
+
+// updateTypes is the update types in priority order, an update is dispatched to
+// the handler of the first matched type which has a registered handler, the
+// updates which have no handler fall through to the OnUpdate handler, then the UpdatesHandler.
+var updateTypes = []struct {
+	typ   string
+	match func(u *tgbotapi.Update) bool
+}{
+	{tgbotapi.UpdateTypeMessage, func(u *tgbotapi.Update) bool { return u.Message != nil }},
+	{tgbotapi.UpdateTypeEditedMessage, func(u *tgbotapi.Update) bool { return u.EditedMessage != nil }},
+	{tgbotapi.UpdateTypeChannelPost, func(u *tgbotapi.Update) bool { return u.ChannelPost != nil }},
+	{tgbotapi.UpdateTypeEditedChannelPost, func(u *tgbotapi.Update) bool { return u.EditedChannelPost != nil }},
+	{tgbotapi.UpdateTypeInlineQuery, func(u *tgbotapi.Update) bool { return u.InlineQuery != nil }},
+	{tgbotapi.UpdateTypeChosenInlineResult, func(u *tgbotapi.Update) bool { return u.ChosenInlineResult != nil }},
+	{tgbotapi.UpdateTypeCallbackQuery, func(u *tgbotapi.Update) bool { return u.CallbackQuery != nil }},
+	{tgbotapi.UpdateTypeShippingQuery, func(u *tgbotapi.Update) bool { return u.ShippingQuery != nil }},
+	{tgbotapi.UpdateTypePreCheckoutQuery, func(u *tgbotapi.Update) bool { return u.PreCheckoutQuery != nil }},
+	{tgbotapi.UpdateTypePoll, func(u *tgbotapi.Update) bool { return u.Poll != nil }},
+	{tgbotapi.UpdateTypePollAnswer, func(u *tgbotapi.Update) bool { return u.PollAnswer != nil }},
+	{tgbotapi.UpdateTypeMyChatMember, func(u *tgbotapi.Update) bool { return u.MyChatMember != nil }},
+	{tgbotapi.UpdateTypeChatMember, func(u *tgbotapi.Update) bool { return u.ChatMember != nil }},
+	{UpdateTypeChatJoinRequest, func(u *tgbotapi.Update) bool { return u.ChatJoinRequest != nil }},
+}
+
+// updateType return the type of the update, empty if the type is unknown.
+func updateType(u *tgbotapi.Update) string {
+	for _, t := range updateTypes {
+		if t.match(u) {
+			return t.typ
+		}
+	}
+	return ""
+}
+
+func (bot *Bot) on(typ string, h Handler) {
+	if h == nil {
+		panic("tgbot: " + typ + " handler must be non-nil")
+	}
+
+	if bot.handlers == nil {
+		bot.handlers = make(map[string]Handler)
+	}
+
+	if _, ok := bot.handlers[typ]; ok {
+		panic("duplicate update handler: " + typ)
+	}
+
+	bot.handlers[typ] = h
+}
+
+// typedHandler return the handler registered for the type of the update.
+func (bot *Bot) typedHandler(u *tgbotapi.Update) (Handler, bool) {
+	for _, t := range updateTypes {
+		if !t.match(u) {
+			continue
+		}
+
+		if h, ok := bot.handlers[t.typ]; ok {
+			return h, true
+		}
+	}
+
+	h, ok := bot.handlers[updateTypeAny]
+	return h, ok
+}
+
+// OnUpdate set the catch-all handler for the updates which are not handled by the other
+// handlers, it takes precedence over the UpdatesHandler.
+func (bot *Bot) OnUpdate(h Handler) {
+	bot.on(updateTypeAny, h)
+}
+
+// OnMessage set the handler for new incoming messages which are not commands.
+func (bot *Bot) OnMessage(h Handler) {
+	bot.on(tgbotapi.UpdateTypeMessage, h)
+}
+
+// OnEditedMessage set the handler for edited messages.
+func (bot *Bot) OnEditedMessage(h Handler) {
+	bot.on(tgbotapi.UpdateTypeEditedMessage, h)
+}
+
+// OnChannelPost set the handler for new incoming channel posts.
+func (bot *Bot) OnChannelPost(h Handler) {
+	bot.on(tgbotapi.UpdateTypeChannelPost, h)
+}
+
+// OnEditedChannelPost set the handler for edited channel posts.
+func (bot *Bot) OnEditedChannelPost(h Handler) {
+	bot.on(tgbotapi.UpdateTypeEditedChannelPost, h)
+}
+
+// OnAnyInlineQuery set the handler for the inline queries which match no OnInlineQuery route.
+func (bot *Bot) OnAnyInlineQuery(h Handler) {
+	bot.on(tgbotapi.UpdateTypeInlineQuery, h)
+}
+
+// OnAnyCallbackQuery set the handler for the callback queries which match no OnCallback route,
+// the callback query is answered automatically if the handler does not answer it.
+func (bot *Bot) OnAnyCallbackQuery(h Handler) {
+	bot.on(tgbotapi.UpdateTypeCallbackQuery, h)
+}
+
+// OnChosenInlineResult set the handler for the chosen inline results.
+func (bot *Bot) OnChosenInlineResult(h Handler) {
+	bot.on(tgbotapi.UpdateTypeChosenInlineResult, h)
+}
+
+// OnShippingQuery set the handler for shipping queries.
+func (bot *Bot) OnShippingQuery(h Handler) {
+	bot.on(tgbotapi.UpdateTypeShippingQuery, h)
+}
+
+// OnPreCheckoutQuery set the handler for pre-checkout queries.
+func (bot *Bot) OnPreCheckoutQuery(h Handler) {
+	bot.on(tgbotapi.UpdateTypePreCheckoutQuery, h)
+}
+
+// OnPoll set the handler for poll state updates.
+func (bot *Bot) OnPoll(h Handler) {
+	bot.on(tgbotapi.UpdateTypePoll, h)
+}
+
+// OnPollAnswer set the handler for poll answers.
+func (bot *Bot) OnPollAnswer(h Handler) {
+	bot.on(tgbotapi.UpdateTypePollAnswer, h)
+}
+
+// OnMyChatMember set the handler for the bot's chat member status updates.
+func (bot *Bot) OnMyChatMember(h Handler) {
+	bot.on(tgbotapi.UpdateTypeMyChatMember, h)
+}
+
+// OnChatMember set the handler for chat member status updates.
+func (bot *Bot) OnChatMember(h Handler) {
+	bot.on(tgbotapi.UpdateTypeChatMember, h)
+}
+
+// OnChatJoinRequest set the handler for the requests to join the chats, the bot must be
+// an administrator with the can_invite_users right to receive them.
+func (bot *Bot) OnChatJoinRequest(h Handler) {
+	bot.on(UpdateTypeChatJoinRequest, h)
+}
+
+// ChatJoinRequest return the chat join request if the update is a chat join request.
+func (c *Context) ChatJoinRequest() *tgbotapi.ChatJoinRequest {
+	if c.update == nil {
+		return nil
+	}
+	return c.update.ChatJoinRequest
+}
+
+// UpdateType return the type of the current update, such as "message", "callback_query".
+func (c *Context) UpdateType() string {
+	if c.update == nil {
+		return ""
+	}
+	return updateType(c.update)
+}
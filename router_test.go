@@ -0,0 +1,63 @@
+package tgbot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestBotRouteByUpdateType(t *testing.T) {
+	var got string
+	record := func(name string) Handler {
+		return func(ctx *Context) error {
+			got = name
+			return nil
+		}
+	}
+
+	bot := NewBot(&tgbotapi.BotAPI{}, WithUpdatesHandler(func(ctx *Context) {
+		got = "updates"
+	}))
+	bot.OnMessage(record("message"))
+	bot.OnPoll(record("poll"))
+	bot.AddCommands(NewCommand("ping", "ping", record("command")))
+
+	tests := []struct {
+		update *tgbotapi.Update
+		except string
+	}{
+		{update: &tgbotapi.Update{Message: &tgbotapi.Message{Text: "hi"}}, except: "message"},
+		{update: newCommandUpdate("/ping"), except: "command"},
+		{update: &tgbotapi.Update{Poll: &tgbotapi.Poll{}}, except: "poll"},
+		{update: &tgbotapi.Update{EditedMessage: &tgbotapi.Message{Text: "hi"}}, except: "updates"},
+	}
+
+	for _, tt := range tests {
+		got = ""
+		bot.makeUpdateHandler(tt.update)()
+		if got != tt.except {
+			t.Errorf("update %s except handled by %q, got: %q", updateType(tt.update), tt.except, got)
+		}
+	}
+
+	bot.OnChatJoinRequest(record("join"))
+	bot.OnAnyCallbackQuery(record("callback"))
+	bot.OnUpdate(record("any"))
+
+	tests = []struct {
+		update *tgbotapi.Update
+		except string
+	}{
+		{update: &tgbotapi.Update{ChatJoinRequest: &tgbotapi.ChatJoinRequest{}}, except: "join"},
+		{update: &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "q", Data: "x"}}, except: "callback"},
+		{update: &tgbotapi.Update{EditedMessage: &tgbotapi.Message{Text: "hi"}}, except: "any"},
+	}
+
+	for _, tt := range tests {
+		got = ""
+		bot.makeUpdateHandler(tt.update)()
+		if got != tt.except {
+			t.Errorf("update %s except handled by %q, got: %q", updateType(tt.update), tt.except, got)
+		}
+	}
+}
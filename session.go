@@ -0,0 +1,338 @@
+package tgbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrNoSessionStore is returned by Context.Session if the session store is not specified.
+var ErrNoSessionStore = errors.New("tgbot: session store is not specified")
+
+// SessionStore stores the encoded sessions.
+type SessionStore interface {
+	// Load return the session data of the key, nil if there is no session.
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+	Delete(key string) error
+}
+
+// SessionKeyFunc return the session key of the update, ok is false if the update has no session.
+type SessionKeyFunc func(update *tgbotapi.Update) (key string, ok bool)
+
+// SessionKeyByChatAndUser is the default session key, every user has its own session in every chat.
+func SessionKeyByChatAndUser(update *tgbotapi.Update) (string, bool) {
+	chat, user := update.FromChat(), update.SentFrom()
+	if chat == nil || user == nil {
+		return "", false
+	}
+	return strconv.FormatInt(chat.ID, 10) + ":" + strconv.FormatInt(user.ID, 10), true
+}
+
+// SessionKeyByChat share the session by all the users in a chat.
+func SessionKeyByChat(update *tgbotapi.Update) (string, bool) {
+	if chat := update.FromChat(); chat != nil {
+		return strconv.FormatInt(chat.ID, 10), true
+	}
+	return "", false
+}
+
+// SessionKeyByUser share the session of a user in all the chats.
+func SessionKeyByUser(update *tgbotapi.Update) (string, bool) {
+	if user := update.SentFrom(); user != nil {
+		return strconv.FormatInt(user.ID, 10), true
+	}
+	return "", false
+}
+
+// Session is the data of the current chat or user, the values are encoded in json,
+// it is saved automatically after the update is handled if it is modified.
+//
+// Session is not safe for concurrent use, use WithShardKey to process the updates
+// of the same session sequentially.
+type Session struct {
+	key    string
+	values map[string]json.RawMessage
+	dirty  bool
+
+	// typed is the values got by SessionValue, they are encoded again before the session is saved.
+	typed map[string]*typedSessionValue
+}
+
+type typedSessionValue struct {
+	v    interface{}
+	data []byte
+}
+
+// Key return the session key.
+func (s *Session) Key() string {
+	return s.key
+}
+
+// Get decode the value of the key into v, report whether the key exists.
+func (s *Session) Get(key string, v interface{}) (bool, error) {
+	data, ok := s.values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// Set set the value of the key, the value must be able to be encoded by json.
+func (s *Session) Set(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.values[key] = data
+	s.dirty = true
+	delete(s.typed, key)
+	return nil
+}
+
+// Delete delete the value of the key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+	delete(s.typed, key)
+}
+
+// Clear delete all the values, the session is deleted from the store.
+func (s *Session) Clear() {
+	if len(s.values) > 0 {
+		s.values = make(map[string]json.RawMessage)
+		s.dirty = true
+	}
+	s.typed = nil
+}
+
+// flush encode the typed values into the session, the session is modified if any of them changed.
+func (s *Session) flush() error {
+	for key, tv := range s.typed {
+		data, err := json.Marshal(tv.v)
+		if err != nil {
+			return fmt.Errorf("failed to encode session value %s, error: %w", key, err)
+		}
+
+		if !bytes.Equal(data, tv.data) {
+			s.values[key] = data
+			s.dirty = true
+			tv.data = data
+		}
+	}
+	return nil
+}
+
+// SessionValue return the value of type T in the session of the current update, e.g.
+//
+//	cart, err := tgbot.SessionValue[Cart](ctx)
+//	cart.Items = append(cart.Items, item)
+//
+// The value is stored under the name of T, such as "main.Cart", the zero value is returned
+// if the session has no value of T. The changes of the value are saved automatically after
+// the update is handled.
+func SessionValue[T any](ctx *Context) (*T, error) {
+	s, err := ctx.Session()
+	if err != nil {
+		return nil, err
+	}
+
+	key := reflect.TypeOf((*T)(nil)).Elem().String()
+	if tv, ok := s.typed[key]; ok {
+		return tv.v.(*T), nil
+	}
+
+	v := new(T)
+	if _, err := s.Get(key, v); err != nil {
+		return nil, fmt.Errorf("failed to decode session value %s, error: %w", key, err)
+	}
+
+	// keep the encoding of the loaded value, so the value is saved only if it is changed.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session value %s, error: %w", key, err)
+	}
+
+	if s.typed == nil {
+		s.typed = make(map[string]*typedSessionValue)
+	}
+	s.typed[key] = &typedSessionValue{v: v, data: data}
+	return v, nil
+}
+
+// Session return the session of the current update, it is loaded on the first call.
+func (c *Context) Session() (*Session, error) {
+	if c.session != nil {
+		return c.session, nil
+	}
+
+	if c.bot == nil || c.bot.opts.sessionStore == nil {
+		return nil, ErrNoSessionStore
+	}
+
+	key, ok := c.bot.opts.sessionKey(c.update)
+	if !ok {
+		return nil, errors.New("tgbot: the update has no session")
+	}
+
+	data, err := c.bot.opts.sessionStore.Load(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session, error: %w", err)
+	}
+
+	s := &Session{key: key, values: make(map[string]json.RawMessage)}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.values); err != nil {
+			return nil, fmt.Errorf("failed to decode session, error: %w", err)
+		}
+	}
+
+	c.session = s
+	return s, nil
+}
+
+// saveSession save the session of the context if it is modified.
+func (bot *Bot) saveSession(ctx *Context) error {
+	s := ctx.session
+	if s == nil {
+		return nil
+	}
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if !s.dirty {
+		return nil
+	}
+
+	store := bot.opts.sessionStore
+	if len(s.values) == 0 {
+		return store.Delete(s.key)
+	}
+
+	data, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	return store.Save(s.key, data)
+}
+
+type memorySessionEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+type memorySessionStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	sessions  map[string]memorySessionEntry
+	lastSweep time.Time
+}
+
+// NewMemorySessionStore new a SessionStore that keep the sessions in memory,
+// the session expires if it is not saved within ttl, zero ttl means never expires.
+func NewMemorySessionStore(ttl time.Duration) SessionStore {
+	return &memorySessionStore{
+		ttl:       ttl,
+		sessions:  make(map[string]memorySessionEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memorySessionStore) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.sessions[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if s.expired(e, time.Now()) {
+		delete(s.sessions, key)
+		return nil, nil
+	}
+	return e.data, nil
+}
+
+func (s *memorySessionStore) Save(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	e := memorySessionEntry{data: data}
+	if s.ttl > 0 {
+		e.expiresAt = now.Add(s.ttl)
+	}
+	s.sessions[key] = e
+
+	// sweep the expired sessions at most once per ttl.
+	if s.ttl > 0 && now.Sub(s.lastSweep) > s.ttl {
+		s.lastSweep = now
+		for k, e := range s.sessions {
+			if s.expired(e, now) {
+				delete(s.sessions, k)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memorySessionStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+	return nil
+}
+
+func (s *memorySessionStore) expired(e memorySessionEntry, now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type fileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore new a SessionStore that keep every session in a file under dir.
+func NewFileSessionStore(dir string) (SessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileSessionStore{dir: dir}, nil
+}
+
+func (s *fileSessionStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+func (s *fileSessionStore) Load(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *fileSessionStore) Save(key string, data []byte) error {
+	return writeFileAtomic(s.path(key), data)
+}
+
+func (s *fileSessionStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package tgbot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestContextSession(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var counts []int
+	bot := NewBot(&tgbotapi.BotAPI{}, WithSessionStore(store))
+	bot.OnMessage(func(ctx *Context) error {
+		s, err := ctx.Session()
+		if err != nil {
+			return err
+		}
+
+		var n int
+		if _, err := s.Get("count", &n); err != nil {
+			return err
+		}
+		counts = append(counts, n)
+		return s.Set("count", n+1)
+	})
+
+	for i := 0; i < 3; i++ {
+		bot.makeUpdateHandler(&tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: 1},
+			From: &tgbotapi.User{ID: 2},
+		}})()
+	}
+
+	if len(counts) != 3 {
+		t.Fatalf("handled except %d, got: %d", 3, len(counts))
+	}
+	for i, n := range counts {
+		if n != i {
+			t.Errorf("count except %d, got: %d", i, n)
+		}
+	}
+
+	if data, _ := store.Load("1:2"); string(data) != `{"count":3}` {
+		t.Errorf("session data except %s, got: %s", `{"count":3}`, data)
+	}
+}
+
+type testCart struct {
+	Items []string `json:"items"`
+}
+
+func TestSessionValue(t *testing.T) {
+	store := NewMemorySessionStore(0)
+	bot := NewBot(&tgbotapi.BotAPI{}, WithSessionStore(store))
+	bot.OnMessage(func(ctx *Context) error {
+		cart, err := SessionValue[testCart](ctx)
+		if err != nil {
+			return err
+		}
+		if ctx.Message().Text != "" {
+			cart.Items = append(cart.Items, ctx.Message().Text)
+		}
+		return nil
+	})
+
+	send := func(text string) {
+		bot.makeUpdateHandler(&tgbotapi.Update{Message: &tgbotapi.Message{
+			Text: text,
+			Chat: &tgbotapi.Chat{ID: 1},
+			From: &tgbotapi.User{ID: 2},
+		}})()
+	}
+
+	send("")
+	if data, _ := store.Load("1:2"); data != nil {
+		t.Errorf("unchanged session except not saved, got: %s", data)
+	}
+
+	send("apple")
+	send("pear")
+
+	except := `{"tgbot.testCart":{"items":["apple","pear"]}}`
+	if data, _ := store.Load("1:2"); string(data) != except {
+		t.Errorf("session data except %s, got: %s", except, data)
+	}
+}
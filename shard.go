@@ -0,0 +1,150 @@
+package tgbot
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ShardKeyFunc return the shard key of the update, the updates with the same key
+// are processed sequentially, ok is false if the update need not be ordered.
+type ShardKeyFunc func(update *tgbotapi.Update) (key int64, ok bool)
+
+// ShardByChat shard the updates by chat id, the updates without chat are sharded by user id.
+func ShardByChat(update *tgbotapi.Update) (int64, bool) {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID, true
+	}
+	return ShardByUser(update)
+}
+
+// ShardByUser shard the updates by the user id who sent the update.
+func ShardByUser(update *tgbotapi.Update) (int64, bool) {
+	if user := update.SentFrom(); user != nil {
+		return user.ID, true
+	}
+	return 0, false
+}
+
+// sequencer runs the funcs of the same key sequentially, and the funcs of different keys concurrently.
+type sequencer struct {
+	mu sync.Mutex
+
+	// queues is the pending funcs of the key, the key exists while its funcs are running.
+	queues map[int64][]func()
+}
+
+func newSequencer() *sequencer {
+	return &sequencer{queues: make(map[int64][]func())}
+}
+
+// do run f after all the previous funcs of the key are done, exec is called
+// to execute the runner if there is no running func of the key.
+func (s *sequencer) do(key int64, f func(), exec func(runner func())) {
+	s.mu.Lock()
+	if q, ok := s.queues[key]; ok {
+		s.queues[key] = append(q, f)
+		s.mu.Unlock()
+		return
+	}
+	s.queues[key] = nil
+	s.mu.Unlock()
+
+	exec(func() {
+		s.run(key, f)
+	})
+}
+
+func (s *sequencer) run(key int64, f func()) {
+	for f != nil {
+		f()
+
+		s.mu.Lock()
+		if q := s.queues[key]; len(q) > 0 {
+			f, s.queues[key] = q[0], q[1:]
+		} else {
+			delete(s.queues, key)
+			f = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// startDispatcher start the dispatcher which reads all the updates in order and
+// dispatches them to the workers by shard key, it exits when updateC is closed.
+func (bot *Bot) startDispatcher() {
+	bot.sequencer = newSequencer()
+
+	if bot.opts.workersPool == nil && bot.opts.workersNum > 0 {
+		bot.workC = make(chan func())
+		for i := 0; i < bot.opts.workersNum; i++ {
+			bot.wg.Add(1)
+			go bot.startShardWorker()
+		}
+	}
+
+	bot.wg.Add(1)
+	go func() {
+		defer bot.wg.Done()
+
+		for update := range bot.updateC {
+			if bot.ctx.Err() != nil && bot.opts.disableHandleAllUpdateOnStop {
+				continue
+			}
+			bot.dispatchUpdate(update)
+		}
+	}()
+}
+
+func (bot *Bot) startShardWorker() {
+	defer bot.wg.Done()
+
+	for {
+		select {
+		case <-bot.ctx.Done():
+			return
+
+		case f := <-bot.workC:
+			f()
+		}
+	}
+}
+
+func (bot *Bot) dispatchUpdate(update *tgbotapi.Update) {
+	updateHandler := bot.makeUpdateHandler(update)
+
+	key, ok := bot.opts.shardKey(update)
+	if !ok {
+		bot.execute(updateHandler)
+		return
+	}
+
+	bot.sequencer.do(key, updateHandler, bot.execute)
+}
+
+// execute run f by the workers pool, the shard workers or a new goroutine,
+// the runner must always be executed, otherwise the key will be blocked.
+func (bot *Bot) execute(f func()) {
+	if bot.opts.workersPool != nil && !bot.opts.workersPool.IsClosed() {
+		err := bot.opts.workersPool.Go(f)
+		if err == nil {
+			return
+		}
+		bot.opts.errHandler(err)
+	}
+
+	if bot.workC != nil {
+		select {
+		case bot.workC <- f:
+			return
+
+		case <-bot.ctx.Done():
+		}
+	}
+
+	bot.wg.Add(1)
+	go func() {
+		defer bot.wg.Done()
+		f()
+	}()
+}
@@ -0,0 +1,40 @@
+package tgbot
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSequencerOrder(t *testing.T) {
+	var (
+		s    = newSequencer()
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		got  = make(map[int64][]int)
+		exec = func(runner func()) { go runner() }
+	)
+
+	for i := 0; i < 300; i++ {
+		i, key := i, int64(i%3)
+
+		wg.Add(1)
+		s.do(key, func() {
+			defer wg.Done()
+			mu.Lock()
+			got[key] = append(got[key], i)
+			mu.Unlock()
+		}, exec)
+	}
+	wg.Wait()
+
+	for key, seq := range got {
+		if len(seq) != 100 {
+			t.Errorf("key %d except %d updates, got: %d", key, 100, len(seq))
+		}
+		for i := 1; i < len(seq); i++ {
+			if seq[i] < seq[i-1] {
+				t.Fatalf("key %d out of order: %v", key, seq)
+			}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package tgbot
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxMessageLength is the maximum length of the message text in UTF-16 code units.
+const MaxMessageLength = 4096
+
+// utf16Len return the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// openEntity is an entity which is open at a position of the text,
+// it is closed at the end of a part and reopened at the start of the next part.
+type openEntity struct {
+	marker string
+	open   string
+	close  string
+}
+
+func closeEntities(stack []openEntity) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString(stack[i].close)
+	}
+	return b.String()
+}
+
+func openEntities(stack []openEntity) string {
+	var b strings.Builder
+	for _, e := range stack {
+		b.WriteString(e.open)
+	}
+	return b.String()
+}
+
+// entityScanner scans the text token by token and tracks the open entities.
+type entityScanner struct {
+	parseMode string
+	stack     []openEntity
+
+	// inLink is true between "[" and "](url)" of markdown, the text is never split there.
+	inLink bool
+}
+
+// splittable report whether the text can be split before the next token.
+func (s *entityScanner) splittable() bool {
+	return !s.inLink
+}
+
+// next return the byte length of the next token of text and update the open entities.
+func (s *entityScanner) next(text string) int {
+	switch s.parseMode {
+	case tgbotapi.ModeHTML:
+		return s.nextHTML(text)
+	case tgbotapi.ModeMarkdown, tgbotapi.ModeMarkdownV2:
+		return s.nextMarkdown(text)
+	default:
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	}
+}
+
+func (s *entityScanner) top() string {
+	if len(s.stack) == 0 {
+		return ""
+	}
+	return s.stack[len(s.stack)-1].marker
+}
+
+func (s *entityScanner) nextHTML(text string) int {
+	switch text[0] {
+	case '<':
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
+			break
+		}
+
+		tag := text[:end+1]
+		if strings.HasPrefix(tag, "</") {
+			if len(s.stack) > 0 {
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+			return len(tag)
+		}
+
+		name := strings.TrimSuffix(tag[1:len(tag)-1], "/")
+		if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+			name = name[:i]
+		}
+		if !strings.HasSuffix(tag, "/>") {
+			s.stack = append(s.stack, openEntity{marker: name, open: tag, close: "</" + name + ">"})
+		}
+		return len(tag)
+
+	case '&':
+		if end := strings.IndexByte(text, ';'); end > 0 && end <= 10 {
+			return end + 1
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(text)
+	return size
+}
+
+func (s *entityScanner) nextMarkdown(text string) int {
+	v2 := s.parseMode == tgbotapi.ModeMarkdownV2
+
+	if text[0] == '\\' && len(text) > 1 {
+		_, size := utf8.DecodeRuneInString(text[1:])
+		return 1 + size
+	}
+
+	// the code and pre blocks only end with their markers.
+	switch s.top() {
+	case "```":
+		if strings.HasPrefix(text, "```") {
+			s.stack = s.stack[:len(s.stack)-1]
+			return 3
+		}
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+
+	case "`":
+		if text[0] == '`' {
+			s.stack = s.stack[:len(s.stack)-1]
+			return 1
+		}
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	}
+
+	switch {
+	case strings.HasPrefix(text, "```"):
+		open := "```"
+		if end := strings.IndexByte(text, '\n'); end >= 0 {
+			open = text[:end+1]
+		}
+		s.stack = append(s.stack, openEntity{marker: "```", open: open, close: "```"})
+		return len(open)
+
+	case text[0] == '`':
+		s.stack = append(s.stack, openEntity{marker: "`", open: "`", close: "`"})
+		return 1
+
+	case text[0] == '[' && !s.inLink:
+		s.inLink = true
+		return 1
+
+	case strings.HasPrefix(text, "](") && s.inLink:
+		end := 2
+		for end < len(text) && text[end] != ')' {
+			if text[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		s.inLink = false
+		if end >= len(text) {
+			return len(text)
+		}
+		return end + 1
+	}
+
+	markers := []string{"*", "_"}
+	if v2 {
+		markers = []string{"||", "__", "*", "_", "~"}
+	}
+	for _, m := range markers {
+		if strings.HasPrefix(text, m) {
+			s.toggle(m)
+			return len(m)
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(text)
+	return size
+}
+
+// toggle close the entity of the marker if it is open, otherwise open it.
+func (s *entityScanner) toggle(marker string) {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		if s.stack[i].marker == marker {
+			s.stack = append(s.stack[:i], s.stack[i+1:]...)
+			return
+		}
+	}
+	s.stack = append(s.stack, openEntity{marker: marker, open: marker, close: marker})
+}
+
+// splitCandidate is a position which the text can be split at.
+type splitCandidate struct {
+	pos   int
+	stack []openEntity
+}
+
+// splitMessage split the text into parts of at most limit UTF-16 code units, the text
+// is split at line breaks if possible, then spaces, and the entities of the parse mode
+// which span parts are closed at the end of a part and reopened in the next part.
+func splitMessage(text, parseMode string, limit int) []string {
+	if utf16Len(text) <= limit {
+		return []string{text}
+	}
+
+	var (
+		parts []string
+		stack []openEntity
+		start int
+	)
+	for start < len(text) {
+		prefix := openEntities(stack)
+		scanner := &entityScanner{parseMode: parseMode, stack: append([]openEntity(nil), stack...)}
+
+		// the last positions after a line break, after a space and anywhere which the text can be split at.
+		var line, space, last *splitCandidate
+
+		units, pos := utf16Len(prefix), start
+		fits := func() bool {
+			return units+utf16Len(closeEntities(scanner.stack)) <= limit
+		}
+		for pos < len(text) {
+			if pos > start && scanner.splittable() && fits() {
+				last = &splitCandidate{pos: pos, stack: append([]openEntity(nil), scanner.stack...)}
+				switch text[pos-1] {
+				case '\n':
+					line = last
+				case ' ':
+					space = last
+				}
+			}
+
+			size := scanner.next(text[pos:])
+			units += utf16Len(text[pos : pos+size])
+			pos += size
+
+			if !fits() {
+				break
+			}
+		}
+
+		var split *splitCandidate
+		switch {
+		case pos >= len(text) && fits():
+			split = &splitCandidate{pos: len(text)}
+		case line != nil:
+			split = line
+		case space != nil:
+			split = space
+		case last != nil:
+			split = last
+		default:
+			// the first token exceeds the limit, split after it anyway.
+			split = &splitCandidate{pos: pos, stack: scanner.stack}
+		}
+
+		parts = append(parts, prefix+text[start:split.pos]+closeEntities(split.stack))
+		start, stack = split.pos, split.stack
+	}
+	return parts
+}
+
+// entityPart is a part of the text with explicit entities.
+type entityPart struct {
+	text     string
+	entities []tgbotapi.MessageEntity
+}
+
+// splitEntities split the text with explicit entities into parts of at most limit UTF-16 code units,
+// the entities are clipped to the parts and their offsets are shifted to the start of the parts.
+func splitEntities(text string, entities []tgbotapi.MessageEntity, limit int) []entityPart {
+	var (
+		parts []entityPart
+		start int
+	)
+	for _, part := range splitMessage(text, "", limit) {
+		end := start + utf16Len(part)
+
+		var clipped []tgbotapi.MessageEntity
+		for _, e := range entities {
+			from, to := e.Offset, e.Offset+e.Length
+			if from < start {
+				from = start
+			}
+			if to > end {
+				to = end
+			}
+			if from >= to {
+				continue
+			}
+
+			e.Offset, e.Length = from-start, to-from
+			clipped = append(clipped, e)
+		}
+
+		parts = append(parts, entityPart{text: part, entities: clipped})
+		start = end
+	}
+	return parts
+}
@@ -0,0 +1,97 @@
+package tgbot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		limit     int
+		except    []string
+	}{
+		{
+			name:   "short",
+			text:   "hello",
+			limit:  10,
+			except: []string{"hello"},
+		},
+		{
+			name:   "lines",
+			text:   "line one\nline two\nline three",
+			limit:  20,
+			except: []string{"line one\nline two\n", "line three"},
+		},
+		{
+			name:   "spaces",
+			text:   "aaaa bbbb cccc",
+			limit:  10,
+			except: []string{"aaaa bbbb ", "cccc"},
+		},
+		{
+			name:   "utf16",
+			text:   strings.Repeat("😀", 3),
+			limit:  4,
+			except: []string{"😀😀", "😀"},
+		},
+		{
+			name:      "html",
+			text:      "<b>bold text\nmore bold</b>",
+			parseMode: tgbotapi.ModeHTML,
+			limit:     20,
+			except:    []string{"<b>bold text\n</b>", "<b>more bold</b>"},
+		},
+		{
+			name:      "pre",
+			text:      "```go\nfmt.Println(1)\nfmt.Println(2)\n```",
+			parseMode: tgbotapi.ModeMarkdownV2,
+			limit:     30,
+			except:    []string{"```go\nfmt.Println(1)\n```", "```go\nfmt.Println(2)\n```"},
+		},
+		{
+			name:      "link",
+			text:      "see [the docs](http://x.y) now",
+			parseMode: tgbotapi.ModeMarkdownV2,
+			limit:     12,
+			except:    []string{"see ", "[the docs](http://x.y)", " now"},
+		},
+	}
+
+	for _, tt := range tests {
+		parts := splitMessage(tt.text, tt.parseMode, tt.limit)
+		if !reflect.DeepEqual(parts, tt.except) {
+			t.Errorf("%s except %q, got: %q", tt.name, tt.except, parts)
+		}
+	}
+}
+
+func TestSplitEntities(t *testing.T) {
+	entities := []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 0, Length: 4},
+		{Type: "italic", Offset: 7, Length: 6},
+		{Type: "code", Offset: 14, Length: 4},
+	}
+
+	parts := splitEntities("😀 aa\nbbbb cccc\ndddd", entities, 10)
+	except := []entityPart{
+		{text: "😀 aa\n", entities: []tgbotapi.MessageEntity{
+			{Type: "bold", Offset: 0, Length: 4},
+		}},
+		{text: "bbbb cccc\n", entities: []tgbotapi.MessageEntity{
+			{Type: "italic", Offset: 1, Length: 6},
+			{Type: "code", Offset: 8, Length: 2},
+		}},
+		{text: "dddd", entities: []tgbotapi.MessageEntity{
+			{Type: "code", Offset: 0, Length: 2},
+		}},
+	}
+	if !reflect.DeepEqual(parts, except) {
+		t.Errorf("parts except %+v, got: %+v", except, parts)
+	}
+}
@@ -0,0 +1,265 @@
+package tgbot
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/imzhongqi/go-tgbot/format"
+)
+
+// RawText is the template data which is not escaped, it must be valid for the parse mode.
+type RawText string
+
+// escapeFunc is the name of the function which escapes the output of the template actions.
+const escapeFunc = "_tgbot_escape"
+
+// templateSet is the common methods of text/template and html/template.
+type templateSet interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// Templates is the registry of the message templates, the templates of HTML parse mode
+// are html/template, and the others are text/template whose action outputs are escaped
+// for the parse mode, RawText and the "raw" function skip escaping.
+//
+// The templates are localized by the directories, e.g.
+//
+//	templates/welcome.tmpl     the default template "welcome"
+//	templates/zh/welcome.tmpl  the template "welcome" for the users whose language is "zh"
+//
+// The language templates fall back to the default templates if not found.
+type Templates struct {
+	parseMode string
+	funcs     map[string]interface{}
+
+	// sources is the template sources by language, "" is the default language.
+	sources map[string]map[string]string
+	sets    map[string]templateSet
+}
+
+// NewTemplates new a Templates of the parse mode, the action outputs are escaped for
+// tgbotapi.ModeMarkdownV2 and the legacy tgbotapi.ModeMarkdown, and not escaped for no parse mode.
+func NewTemplates(parseMode string) *Templates {
+	return &Templates{
+		parseMode: parseMode,
+		funcs:     make(map[string]interface{}),
+		sources:   make(map[string]map[string]string),
+		sets:      make(map[string]templateSet),
+	}
+}
+
+// ParseMode return the parse mode of the templates.
+func (t *Templates) ParseMode() string {
+	return t.parseMode
+}
+
+// Funcs add the functions to the templates, it must be called before ParseFS.
+func (t *Templates) Funcs(funcs map[string]interface{}) *Templates {
+	for name, fn := range funcs {
+		t.funcs[name] = fn
+	}
+	return t
+}
+
+// ParseFS parse the templates under the root directory of fsys, the files in root are the
+// default templates and the files in the subdirectories are the templates of the language
+// of the directory name, the template name is the file name without extension.
+func (t *Templates) ParseFS(fsys fs.FS, root string) error {
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel := p
+		if root != "." {
+			rel = strings.TrimPrefix(p, root+"/")
+		}
+		lang, file := path.Split(rel)
+		if strings.Contains(strings.TrimSuffix(lang, "/"), "/") {
+			return nil
+		}
+		lang = strings.ToLower(strings.TrimSuffix(lang, "/"))
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		if t.sources[lang] == nil {
+			t.sources[lang] = make(map[string]string)
+		}
+		t.sources[lang][strings.TrimSuffix(file, path.Ext(file))] = string(content)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read templates, error: %w", err)
+	}
+
+	return t.build()
+}
+
+// build parse every language with the default templates as the fallbacks.
+func (t *Templates) build() error {
+	for lang := range t.sources {
+		sources := make(map[string]string, len(t.sources[""])+len(t.sources[lang]))
+		for name, src := range t.sources[""] {
+			sources[name] = src
+		}
+		for name, src := range t.sources[lang] {
+			sources[name] = src
+		}
+
+		set, err := t.parse(sources)
+		if err != nil {
+			return fmt.Errorf("failed to parse templates of language %q, error: %w", lang, err)
+		}
+		t.sets[lang] = set
+	}
+	return nil
+}
+
+func (t *Templates) parse(sources map[string]string) (templateSet, error) {
+	if t.parseMode == tgbotapi.ModeHTML {
+		set := htmltemplate.New("").Funcs(htmltemplate.FuncMap{
+			"raw": func(s string) htmltemplate.HTML { return htmltemplate.HTML(s) },
+		}).Funcs(t.funcs)
+		for name, src := range sources {
+			if _, err := set.New(name).Parse(src); err != nil {
+				return nil, err
+			}
+		}
+		return set, nil
+	}
+
+	set := template.New("").Funcs(template.FuncMap{
+		"raw":      func(s string) RawText { return RawText(s) },
+		escapeFunc: t.escape,
+	}).Funcs(t.funcs)
+	for name, src := range sources {
+		if _, err := set.New(name).Parse(src); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tmpl := range set.Templates() {
+		if tmpl.Tree != nil {
+			escapeActions(tmpl.Tree.Root)
+		}
+	}
+	return set, nil
+}
+
+func (t *Templates) escape(v interface{}) string {
+	if raw, ok := v.(RawText); ok {
+		return string(raw)
+	}
+	return format.Escape(t.parseMode, fmt.Sprint(v))
+}
+
+// escapeActions append the escape function to the pipelines of the actions which output text.
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+
+	case *parse.ActionNode:
+		// the variable declarations output nothing.
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(escapeFunc).SetPos(n.Pos)},
+		})
+
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+// languages return the candidate languages of lang, e.g. "pt-br", "pt" and the default language.
+func languages(lang string) []string {
+	lang = strings.ToLower(lang)
+	candidates := []string{lang}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		candidates = append(candidates, lang[:i])
+	}
+	return append(candidates, "")
+}
+
+// Execute execute the template of the name in the language, it falls back to the default language.
+func (t *Templates) Execute(w io.Writer, lang, name string, data interface{}) error {
+	for _, l := range languages(lang) {
+		set, ok := t.sets[l]
+		if !ok || !hasTemplate(set, name) {
+			continue
+		}
+		return set.ExecuteTemplate(w, name, data)
+	}
+	return fmt.Errorf("template %s not found", name)
+}
+
+func hasTemplate(set templateSet, name string) bool {
+	switch s := set.(type) {
+	case *template.Template:
+		return s.Lookup(name) != nil
+	case *htmltemplate.Template:
+		return s.Lookup(name) != nil
+	default:
+		return false
+	}
+}
+
+// RenderTemplate render the template of the name in the language of the sender.
+func (c *Context) RenderTemplate(name string, data interface{}) (string, error) {
+	templates := c.bot.opts.templates
+	if templates == nil {
+		return "", fmt.Errorf("template %s not found, the templates are not set", name)
+	}
+
+	var lang string
+	if user := c.SentFrom(); user != nil {
+		lang = user.LanguageCode
+	}
+
+	var buf bytes.Buffer
+	if err := templates.Execute(&buf, lang, name, data); err != nil {
+		return "", fmt.Errorf("failed to render template, error: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ReplyTemplate reply the template of the name rendered in the language of the sender.
+func (c *Context) ReplyTemplate(name string, data interface{}, opts ...MessageOption) error {
+	text, err := c.RenderTemplate(name, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.reply(text, mergeOpts(opts,
+		WithParseMode(c.bot.opts.templates.parseMode),
+		WithDisableWebPagePreview(true),
+	)...)
+	return err
+}
@@ -0,0 +1,75 @@
+package tgbot
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/welcome.tmpl":    {Data: []byte(`*Hi* {{.Name}}{{if .VIP}} {{raw "\\(vip\\)"}}{{end}}!`)},
+		"templates/bye.tmpl":        {Data: []byte(`Bye {{.Name}}.`)},
+		"templates/zh/welcome.tmpl": {Data: []byte(`*你好* {{.Name}}`)},
+	}
+
+	templates := NewTemplates(tgbotapi.ModeMarkdownV2)
+	if err := templates.ParseFS(fsys, "templates"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{"Name": "a.b", "VIP": true}
+	tests := []struct {
+		lang, name, except string
+	}{
+		{lang: "en", name: "welcome", except: `*Hi* a\.b \(vip\)!`},
+		{lang: "zh-hans", name: "welcome", except: `*你好* a\.b`},
+		{lang: "zh", name: "bye", except: `Bye a\.b.`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := templates.Execute(&buf, tt.lang, tt.name, data); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.except {
+			t.Errorf("%s in %s except %q, got: %q", tt.name, tt.lang, tt.except, buf.String())
+		}
+	}
+}
+
+func TestMarkdownTemplates(t *testing.T) {
+	templates := NewTemplates(tgbotapi.ModeMarkdown)
+	if err := templates.ParseFS(fstest.MapFS{"hi.tmpl": {Data: []byte(`*Hi* {{.}}`)}}, "."); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templates.Execute(&buf, "", "hi", "snake_case*"); err != nil {
+		t.Fatal(err)
+	}
+	if except := `*Hi* snake\_case\*`; buf.String() != except {
+		t.Errorf("hi except %q, got: %q", except, buf.String())
+	}
+}
+
+func TestHTMLTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.html": {Data: []byte(`<b>Hello</b> {{.}}`)},
+	}
+
+	templates := NewTemplates(tgbotapi.ModeHTML)
+	if err := templates.ParseFS(fsys, "."); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := templates.Execute(&buf, "", "hello", "<world>"); err != nil {
+		t.Fatal(err)
+	}
+	if except := "<b>Hello</b> &lt;world&gt;"; buf.String() != except {
+		t.Errorf("except %q, got: %q", except, buf.String())
+	}
+}
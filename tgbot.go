@@ -14,6 +14,9 @@ import (
 type Bot struct {
 	api *tgbotapi.BotAPI
 
+	// ctxAPI is the api of the Context, it is different from api if the rate limiter is specified.
+	ctxAPI *tgbotapi.BotAPI
+
 	// opts is bot options
 	opts *options
 
@@ -26,7 +29,51 @@ type Bot struct {
 
 	commands map[string]*Command
 
+	// commandIndex is the commands keyed by the normalized names and aliases.
+	commandIndex map[string]*Command
+
+	// handlers is the update handlers keyed by update type.
+	handlers map[string]Handler
+
+	// callbacks is the callback query routes.
+	callbacks []*callbackRoute
+
+	// conversations is the conversations started by commands.
+	conversations map[string]*Conversation
+
+	// middlewares is the global middlewares, they wrap all the handlers.
+	middlewares []Middleware
+
 	updateC chan *tgbotapi.Update
+
+	// updateMu guards updateC against sending on a closed channel by the webhook handler.
+	updateMu     sync.RWMutex
+	updateClosed bool
+
+	// sequencer and workC are used to process the updates in order by shard key.
+	sequencer *sequencer
+	workC     chan func()
+
+	// breaker is the circuit breaker of getting updates.
+	breaker *breaker
+
+	// fatalErr is the error which stops polling updates, Run returns it.
+	fatalErr error
+
+	// offsets is non-nil if the offset store is specified.
+	offsets *offsetTracker
+
+	deepLinks []*deepLinkRoute
+
+	// inlineQueries is the inline query routes registered by OnInlineQuery.
+	inlineQueries []*callbackRoute
+
+	// admins caches the chat administrators for RoleChatAdmin and RoleChatCreator.
+	admins *adminCache
+
+	// pageStore is the callback store set by AddPagination if the bot has no store,
+	// its ttl covers all the paginations.
+	pageStore *memoryCallbackStore
 }
 
 // NewBot new a telegram bot.
@@ -44,12 +91,24 @@ func NewBot(api *tgbotapi.BotAPI, opts ...Option) *Bot {
 		o.bufSize = o.limit
 	}
 
+	// the client of the handlers waits for the rate limiter and turns the gateway errors into
+	// the server errors for the retry policy.
+	ctxAPI := api
+	if o.rateLimiter != nil || o.retryPolicy != nil {
+		ctxAPI = new(tgbotapi.BotAPI)
+		*ctxAPI = *api
+		ctxAPI.Client = &client{cli: api.Client, ctx: ctx, limiter: o.rateLimiter}
+	}
+
 	return &Bot{
 		api:     api,
+		ctxAPI:  ctxAPI,
 		opts:    o,
 		ctx:     ctx,
 		cancel:  cancel,
 		updateC: make(chan *tgbotapi.Update, o.bufSize),
+		breaker: &breaker{threshold: o.circuitBreakerThreshold, cooldown: o.circuitBreakerCooldown},
+		admins:  newAdminCache(o.adminCacheTTL),
 	}
 }
 
@@ -74,17 +133,33 @@ func (bot *Bot) allocateContextWithUpdate(update *tgbotapi.Update) (c *Context,
 	if v := bot.pool.Get(); v != nil {
 		c = v.(*Context)
 		c.Context = ctx
+		c.BotAPI = bot.contextAPI(ctx)
 		c.update = update
 		return c, recycle
 	}
 
 	return &Context{
 		Context: ctx,
-		BotAPI:  bot.api,
+		BotAPI:  bot.contextAPI(ctx),
+		bot:     bot,
 		update:  update,
 	}, recycle
 }
 
+// contextAPI return the api whose requests are bound to ctx, so the rate limiter
+// waiting respects the handler timeout and Stop.
+func (bot *Bot) contextAPI(ctx context.Context) *tgbotapi.BotAPI {
+	cli, ok := bot.ctxAPI.Client.(*client)
+	if !ok {
+		return bot.ctxAPI
+	}
+
+	api := new(tgbotapi.BotAPI)
+	*api = *bot.ctxAPI
+	api.Client = cli.withContext(ctx)
+	return api
+}
+
 type multiErr []error
 
 func (e multiErr) Error() string {
@@ -137,6 +212,7 @@ func (bot *Bot) ClearBotCommands() error {
 func (bot *Bot) AddCommands(commands ...*Command) {
 	if bot.commands == nil {
 		bot.commands = make(map[string]*Command)
+		bot.commandIndex = make(map[string]*Command)
 	}
 
 	for _, c := range commands {
@@ -149,12 +225,44 @@ func (bot *Bot) AddCommands(commands ...*Command) {
 			panic("tgbot: command handler must be non-nil")
 		}
 
-		if _, ok := bot.commands[c.Name]; ok {
-			panic("duplicate command name: " + c.Name)
+		for _, name := range append([]string{c.Name}, c.aliases...) {
+			key := bot.normalizeCommand(name)
+			if _, ok := bot.commandIndex[key]; ok {
+				panic("duplicate command name: " + name)
+			}
+			bot.commandIndex[key] = c
 		}
 
 		bot.commands[c.Name] = c
+
+		if c.conversation != nil {
+			bot.addConversation(c.conversation)
+		}
+	}
+}
+
+func (bot *Bot) normalizeCommand(name string) string {
+	if bot.opts.caseInsensitiveCommands {
+		return strings.ToLower(name)
 	}
+	return name
+}
+
+// lookupCommand return the command by name or alias.
+func (bot *Bot) lookupCommand(name string) (*Command, bool) {
+	cmd, ok := bot.commandIndex[bot.normalizeCommand(name)]
+	return cmd, ok
+}
+
+// isAddressedToOther report whether the command is addressed to another bot, such as /cmd@otherbot.
+func (bot *Bot) isAddressedToOther(ctx *Context) bool {
+	msg := ctx.Message()
+	if msg == nil || !msg.IsCommand() {
+		return false
+	}
+
+	_, username, ok := strings.Cut(msg.CommandWithAt(), "@")
+	return ok && bot.api.Self.UserName != "" && !strings.EqualFold(username, bot.api.Self.UserName)
 }
 
 func (bot *Bot) Commands() map[string]*Command {
@@ -218,6 +326,8 @@ func (bot *Bot) setupCommands() error {
 
 func (bot *Bot) makeUpdateHandler(update *tgbotapi.Update) func() {
 	return func() {
+		defer bot.commitUpdate(update)
+
 		ctx, recycle := bot.allocateContextWithUpdate(update)
 		defer recycle()
 
@@ -229,12 +339,17 @@ func (bot *Bot) makeUpdateHandler(update *tgbotapi.Update) func() {
 			}()
 		}
 
-		switch {
-		case bot.commands != nil && ctx.IsCommand():
-			bot.commandHandler(ctx)
+		// the administrators may change, the cache is refreshed on the next check.
+		if m := update.ChatMember; m != nil {
+			bot.admins.invalidate(m.Chat.ID)
+		}
 
-		default:
-			bot.updatesHandler(ctx)
+		if err := chain(bot.route(ctx), bot.middlewares...)(ctx); err != nil {
+			bot.opts.errHandler(err)
+		}
+
+		if err := bot.saveSession(ctx); err != nil {
+			bot.opts.errHandler(fmt.Errorf("failed to save session, error: %w", err))
 		}
 	}
 }
@@ -245,6 +360,7 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 	if bot.opts.workersPool != nil && !bot.opts.workersPool.IsClosed() {
 		if err := bot.opts.workersPool.Go(updateHandler); err != nil {
 			bot.opts.errHandler(err)
+			bot.commitUpdate(update)
 		}
 		return
 	}
@@ -258,24 +374,114 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 	updateHandler()
 }
 
-func (bot *Bot) commandHandler(ctx *Context) {
-	handler := bot.undefinedCmdHandler
+// route return the handler of the update, the priority is: active conversation, callback routes,
+// commands, the handlers registered by update type, the OnUpdate handler, then the updates handler.
+func (bot *Bot) route(ctx *Context) Handler {
+	if bot.isAddressedToOther(ctx) {
+		return ignoreHandler
+	}
+
+	if bot.conversations != nil {
+		if h, ok := bot.conversationRoute(ctx); ok {
+			return h
+		}
+	}
+
+	if bot.inlineQueries != nil {
+		if h, ok := bot.inlineQueryRoute(ctx); ok {
+			return h
+		}
+	}
 
-	if cmd, ok := bot.commands[ctx.Command()]; ok {
-		handler = cmd.Handler
+	if bot.deepLinks != nil {
+		if h, ok := bot.deepLinkRoute(ctx); ok {
+			return h
+		}
+	}
+
+	switch {
+	case ctx.CallbackQuery() != nil && (bot.callbacks != nil || bot.handlers[tgbotapi.UpdateTypeCallbackQuery] != nil):
+		return bot.callbackHandler
+
+	case bot.commands != nil && ctx.IsCommand():
+		return bot.commandHandler
 	}
 
-	if err := handler(ctx); err != nil {
-		bot.opts.errHandler(err)
+	if h, ok := bot.typedHandler(ctx.update); ok {
+		return h
 	}
+
+	return bot.updatesHandler
 }
 
-func (bot *Bot) updatesHandler(ctx *Context) {
-	if bot.opts.updatesHandler == nil {
+// commitUpdate commit the offset of the update to the offset store.
+func (bot *Bot) commitUpdate(update *tgbotapi.Update) {
+	if bot.offsets == nil {
 		return
 	}
 
+	if err := bot.offsets.commit(update.UpdateID); err != nil {
+		bot.opts.errHandler(fmt.Errorf("failed to save offset, error: %w", err))
+	}
+}
+
+func (bot *Bot) commandHandler(ctx *Context) error {
+	if cmd, ok := bot.lookupCommand(ctx.Command()); ok {
+		handler := cmd.Handler
+		if len(cmd.args) > 0 {
+			handler = bot.argsHandler(cmd, handler)
+		}
+		handler = chain(handler, cmd.middlewares...)
+		if conv := cmd.conversation; conv != nil {
+			h := handler
+			handler = func(ctx *Context) error {
+				return bot.startConversation(ctx, conv, h)
+			}
+		}
+		return bot.guardCommand(cmd, handler)(ctx)
+	}
+
+	return bot.undefinedCmdHandler(ctx)
+}
+
+// guardCommand wrap the handler with the roles and the cooldown checks of the command.
+func (bot *Bot) guardCommand(cmd *Command, h Handler) Handler {
+	return func(ctx *Context) error {
+		if len(cmd.roles) > 0 {
+			allowed, err := bot.authorize(ctx, cmd)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return bot.forbiddenHandler(ctx)
+			}
+		}
+
+		if cmd.cooldown != nil {
+			allowed, wait, err := bot.checkCooldown(ctx, cmd)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return bot.cooldownHandler(ctx, wait)
+			}
+		}
+
+		return h(ctx)
+	}
+}
+
+func ignoreHandler(*Context) error {
+	return nil
+}
+
+func (bot *Bot) updatesHandler(ctx *Context) error {
+	if bot.opts.updatesHandler == nil {
+		return nil
+	}
+
 	bot.opts.updatesHandler(ctx)
+	return nil
 }
 
 func (bot *Bot) undefinedCmdHandler(ctx *Context) error {
@@ -301,6 +507,11 @@ func (bot *Bot) startWorker() {
 }
 
 func (bot *Bot) startWorkers() {
+	if bot.opts.shardKey != nil {
+		bot.startDispatcher()
+		return
+	}
+
 	workNum := bot.opts.workersNum
 	if workNum <= 0 {
 		workNum = 1
@@ -325,10 +536,25 @@ func (bot *Bot) hijackAPI() *tgbotapi.BotAPI {
 	return api
 }
 
+// getUpdates get updates, it is retried by the retry policy if specified,
+// except the probe of the half-open circuit.
+func (bot *Bot) getUpdates(api *tgbotapi.BotAPI, config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	if bot.opts.retryPolicy == nil || bot.breaker.probing() {
+		return api.GetUpdates(config)
+	}
+
+	var updates []tgbotapi.Update
+	err := bot.opts.retryPolicy.do(bot.ctx, true, func() (err error) {
+		updates, err = api.GetUpdates(config)
+		return err
+	})
+	return updates, err
+}
+
 func (bot *Bot) pollUpdates() {
 	defer func() {
 		bot.wg.Done()
-		close(bot.updateC)
+		bot.closeUpdateC()
 	}()
 
 	api := bot.hijackAPI()
@@ -341,28 +567,66 @@ func (bot *Bot) pollUpdates() {
 		default:
 		}
 
-		updates, err := api.GetUpdates(tgbotapi.UpdateConfig{
+		if !bot.waitCircuit() {
+			return
+		}
+
+		// poll from the committed offset, so the updates that are not handled
+		// will not be confirmed and can be redelivered after restart.
+		offset := bot.opts.offset
+		if bot.offsets != nil {
+			offset = bot.offsets.committed()
+		}
+
+		updates, err := bot.getUpdates(api, tgbotapi.UpdateConfig{
 			Limit:          bot.opts.limit,
-			Offset:         bot.opts.offset,
+			Offset:         offset,
 			Timeout:        bot.opts.updateTimeout,
 			AllowedUpdates: bot.opts.allowedUpdates,
 		})
 		if err != nil {
-			if errors.Is(err, context.Canceled) {
+			if errors.Is(err, context.Canceled) || !bot.handlePollUpdatesError(err) {
 				return
 			}
-
-			bot.opts.pollUpdatesErrorHandler(err)
 			continue
 		}
+		bot.breaker.success()
 
-		for _, update := range updates {
+		dispatched := false
+		for i := range updates {
+			update := &updates[i]
 			if update.UpdateID >= bot.opts.offset {
 				bot.opts.offset = update.UpdateID + 1
-				bot.updateC <- &update
+				if bot.offsets != nil {
+					bot.offsets.dispatch(update.UpdateID)
+				}
+				bot.updateC <- update
+				dispatched = true
 			}
 		}
+
+		// all the updates are being processed, wait for some of them to be committed.
+		if bot.offsets != nil && len(updates) > 0 && !dispatched {
+			bot.offsets.wait(bot.ctx, offset)
+		}
+	}
+}
+
+func (bot *Bot) loadOffset() error {
+	if bot.opts.offsetStore == nil {
+		return nil
+	}
+
+	offset, err := bot.opts.offsetStore.Load()
+	if err != nil {
+		return err
+	}
+
+	if offset > bot.opts.offset {
+		bot.opts.offset = offset
 	}
+	bot.offsets = newOffsetTracker(bot.opts.offsetStore, bot.opts.offset)
+	return nil
 }
 
 func (bot *Bot) Run() error {
@@ -371,6 +635,10 @@ func (bot *Bot) Run() error {
 		return fmt.Errorf("failed to setup commands, error: %w", err)
 	}
 
+	if err := bot.loadOffset(); err != nil {
+		return fmt.Errorf("failed to load offset, error: %w", err)
+	}
+
 	// start the worker.
 	bot.startWorkers()
 
@@ -380,13 +648,17 @@ func (bot *Bot) Run() error {
 	// wait all worker done.
 	bot.wg.Wait()
 
+	if bot.fatalErr != nil {
+		return fmt.Errorf("failed to get updates, error: %w", bot.fatalErr)
+	}
 	return nil
 }
 
 func (bot *Bot) Stop() context.Context {
 	bot.cancel()
 
-	if !bot.opts.disableHandleAllUpdateOnStop {
+	// the dispatcher handles the remaining updates in order if sharding.
+	if !bot.opts.disableHandleAllUpdateOnStop && bot.opts.shardKey == nil {
 		// must be processed until all updates are processed.
 		for update := range bot.updateC {
 			bot.wg.Add(1)
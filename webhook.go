@@ -0,0 +1,162 @@
+package tgbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// WebhookSecretTokenHeader is the header which telegram carries the secret token in every webhook request.
+const WebhookSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxWebhookBodySize is the maximum size of the webhook update body.
+const maxWebhookBodySize = 1 << 20
+
+// ServeHTTP implements http.Handler, it receives the webhook update and
+// sends it to the workers, so the update is processed the same way as pollUpdates.
+func (bot *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if secret := bot.opts.webhookSecretToken; secret != "" {
+		token := r.Header.Get(WebhookSecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	update := new(tgbotapi.Update)
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodySize)).Decode(update); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	bot.updateMu.RLock()
+	defer bot.updateMu.RUnlock()
+
+	if bot.updateClosed {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case bot.updateC <- update:
+		w.WriteHeader(http.StatusOK)
+
+	case <-bot.ctx.Done():
+		// let telegram redeliver the update later.
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+	case <-r.Context().Done():
+	}
+}
+
+// closeUpdateC close the updateC, it is safe for concurrent use with ServeHTTP.
+func (bot *Bot) closeUpdateC() {
+	bot.updateMu.Lock()
+	defer bot.updateMu.Unlock()
+
+	if !bot.updateClosed {
+		bot.updateClosed = true
+		close(bot.updateC)
+	}
+}
+
+func (bot *Bot) setWebhook() error {
+	if bot.opts.webhookURL == "" {
+		return nil
+	}
+
+	params := tgbotapi.Params{"url": bot.opts.webhookURL}
+	params.AddNonEmpty("secret_token", bot.opts.webhookSecretToken)
+	params.AddNonZero("max_connections", bot.opts.webhookMaxConnections)
+	params.AddBool("drop_pending_updates", bot.opts.webhookDropPendingUpdates)
+	if err := params.AddInterface("allowed_updates", bot.opts.allowedUpdates); err != nil {
+		return err
+	}
+
+	_, err := bot.api.MakeRequest("setWebhook", params)
+	return err
+}
+
+// deleteWebhook delete the webhook set by setWebhook, the webhook managed by others is kept.
+func (bot *Bot) deleteWebhook() error {
+	if bot.opts.webhookURL == "" || bot.opts.disableDeleteWebhookOnStop {
+		return nil
+	}
+
+	_, err := bot.api.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
+
+func (bot *Bot) serveWebhook(addr string) (err error) {
+	defer func() {
+		bot.closeUpdateC()
+
+		if e := bot.deleteWebhook(); e != nil {
+			bot.opts.errHandler(fmt.Errorf("failed to delete webhook, error: %w", e))
+		}
+	}()
+
+	// the handler is mounted by the caller.
+	if addr == "" {
+		<-bot.ctx.Done()
+		return nil
+	}
+
+	srv := &http.Server{Addr: addr, Handler: bot}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errC:
+		bot.cancel()
+		return err
+
+	case <-bot.ctx.Done():
+		return srv.Shutdown(context.Background())
+	}
+}
+
+// RunWebhook run the bot in webhook mode, it sets the webhook if WithWebhook is specified,
+// then listens on addr and receives updates until Stop is called. If addr is empty, no
+// listener is started and the Bot must be mounted as a http.Handler on an existing server.
+func (bot *Bot) RunWebhook(addr string) error {
+	// setup bot commands.
+	if err := bot.setupCommands(); err != nil {
+		return fmt.Errorf("failed to setup commands, error: %w", err)
+	}
+
+	if err := bot.setWebhook(); err != nil {
+		return fmt.Errorf("failed to set webhook, error: %w", err)
+	}
+
+	// start the worker.
+	bot.startWorkers()
+
+	// start receive webhook updates.
+	var serveErr error
+	bot.wg.Add(1)
+	go func() {
+		defer bot.wg.Done()
+		serveErr = bot.serveWebhook(addr)
+	}()
+
+	// wait all worker done.
+	bot.wg.Wait()
+
+	if serveErr != nil {
+		return fmt.Errorf("failed to serve webhook, error: %w", serveErr)
+	}
+	return nil
+}
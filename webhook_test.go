@@ -0,0 +1,93 @@
+package tgbot_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/imzhongqi/go-tgbot"
+)
+
+func TestBotServeHTTP(t *testing.T) {
+	var methods []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		fmt.Fprint(w, `{"ok": true, "result": true}`)
+	}))
+	defer apiSrv.Close()
+
+	api := &tgbotapi.BotAPI{Client: apiSrv.Client()}
+	api.SetAPIEndpoint(apiSrv.URL + "/bot%s/%s")
+
+	received := make(chan int, 1)
+	bot := tgbot.NewBot(api,
+		tgbot.WithDisableAutoSetupCommands(true),
+		tgbot.WithWebhookSecretToken("secret"),
+		tgbot.WithUpdatesHandler(func(ctx *tgbot.Context) {
+			received <- ctx.Update().UpdateID
+		}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.RunWebhook("")
+	}()
+
+	srv := httptest.NewServer(bot)
+	defer srv.Close()
+
+	post := func(token, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set(tgbot.WebhookSecretTokenHeader, token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("wrong", `{"update_id": 1}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret token except %d, got: %d", http.StatusUnauthorized, code)
+	}
+
+	if code := post("secret", `{`); code != http.StatusBadRequest {
+		t.Errorf("malformed update except %d, got: %d", http.StatusBadRequest, code)
+	}
+
+	if code := post("secret", `{"message": {"text": "`+strings.Repeat("a", 2<<20)+`"}}`); code != http.StatusBadRequest {
+		t.Errorf("oversized update except %d, got: %d", http.StatusBadRequest, code)
+	}
+
+	if code := post("secret", `{"update_id": 2}`); code != http.StatusOK {
+		t.Errorf("valid update except %d, got: %d", http.StatusOK, code)
+	}
+
+	select {
+	case id := <-received:
+		if id != 2 {
+			t.Errorf("update id except %d, got: %d", 2, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update is not handled")
+	}
+
+	<-bot.Stop().Done()
+
+	if err := <-done; err != nil {
+		t.Errorf("RunWebhook except nil error, got: %v", err)
+	}
+
+	// the webhook is not set by RunWebhook, so it is not deleted on stop.
+	if len(methods) != 0 {
+		t.Errorf("api requests except none, got: %v", methods)
+	}
+
+	if code := post("secret", `{"update_id": 3}`); code != http.StatusServiceUnavailable {
+		t.Errorf("stopped bot except %d, got: %d", http.StatusServiceUnavailable, code)
+	}
+}